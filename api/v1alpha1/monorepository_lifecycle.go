@@ -4,23 +4,126 @@ import (
 	"context"
 
 	"github.com/vmware-labs/reconciler-runtime/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	MonoRepositoryConditionReady = apis.ConditionReady
+	// MonoRepositoryConditionSourceReady is True when the source of the mono
+	// repository is ready with an artifact.
+	MonoRepositoryConditionSourceReady = "SourceReady"
+	// MonoRepositoryConditionArtifactDownloaded is True when the artifact of the
+	// source has been downloaded.
+	MonoRepositoryConditionArtifactDownloaded = "ArtifactDownloaded"
+	// MonoRepositoryConditionArtifactFiltered is True when the files of the
+	// artifact have been filtered and hashed.
+	MonoRepositoryConditionArtifactFiltered = "ArtifactFiltered"
+	// MonoRepositoryConditionArtifactPublished is True when the filtered artifact
+	// has been stored and is being served.
+	MonoRepositoryConditionArtifactPublished = "ArtifactPublished"
 
 	MonoRepositorySucceededReason = "Succeeded"
 	MonoRepositoryFailedReason    = "Failed"
+
+	SourceNotFoundReason      = "SourceNotFound"
+	SourceNotReadyReason      = "SourceNotReady"
+	SourceConflictReason      = "SourceConflict"
+	InvalidSourceReason       = "InvalidSource"
+	SourceOutOfScopeReason    = "SourceOutOfScope"
+	SourceAccessDeniedReason  = "SourceAccessDenied"
+	DownloadFailedReason      = "DownloadFailed"
+	ExtractFailedReason       = "ExtractFailed"
+	HashFailedReason          = "HashFailed"
+	IncludeFileNotFoundReason = "IncludeFileNotFound"
+	NoFilesMatchedReason      = "NoFilesMatched"
+	PublishFailedReason       = "PublishFailed"
 )
 
-var containerCondSet = apis.NewLivingConditionSet(
-	MonoRepositoryConditionReady,
+var containerCondSet = apis.NewLivingConditionSetWithHappyReason(
+	MonoRepositorySucceededReason,
+	MonoRepositoryConditionSourceReady,
+	MonoRepositoryConditionArtifactDownloaded,
+	MonoRepositoryConditionArtifactFiltered,
+	MonoRepositoryConditionArtifactPublished,
 )
 
+func (b *MonoRepositoryStatus) InitializeConditions(ctx context.Context) {
+	containerCondSet.ManageWithContext(ctx, b).InitializeConditions()
+}
+
+// MarkFailed marks the MonoRepository as not ready for a reason that is not
+// specific to any phase of the reconcile, e.g. an invalid spec.
 func (b *MonoRepositoryStatus) MarkFailed(ctx context.Context, err error) {
 	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionReady, MonoRepositoryFailedReason, err.Error())
 }
 
+func (b *MonoRepositoryStatus) MarkSourceReady(ctx context.Context, source string, revision string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositoryConditionSourceReady, MonoRepositorySucceededReason, "%s is ready with revision %s", source, revision)
+}
+
+// MarkSourceNotReady reflects the Ready condition of the source, a source that
+// has failed is False while a source that is still progressing is Unknown.
+func (b *MonoRepositoryStatus) MarkSourceNotReady(ctx context.Context, source string, ready *metav1.Condition) {
+	switch {
+	case ready != nil && ready.Status == metav1.ConditionFalse:
+		containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionSourceReady, SourceNotReadyReason, "%s is not ready: %s", source, ready.Message)
+	case ready != nil && ready.Status == metav1.ConditionTrue:
+		containerCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositoryConditionSourceReady, SourceNotReadyReason, "%s does not have an artifact", source)
+	case ready != nil && ready.Message != "":
+		containerCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositoryConditionSourceReady, SourceNotReadyReason, "waiting for %s to become ready: %s", source, ready.Message)
+	default:
+		containerCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositoryConditionSourceReady, SourceNotReadyReason, "waiting for %s to become ready", source)
+	}
+}
+
+func (b *MonoRepositoryStatus) MarkSourceNotFound(ctx context.Context, source string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionSourceReady, SourceNotFoundReason, "%s not found", source)
+}
+
+// MarkSourceOutOfScope records that the source is in a namespace the controller
+// does not watch, so it can neither be read nor tracked.
+func (b *MonoRepositoryStatus) MarkSourceOutOfScope(ctx context.Context, source string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionSourceReady, SourceOutOfScopeReason, "%s is in a namespace that is not watched by the controller", source)
+}
+
+// MarkSourceAccessDenied records that the source is in another namespace while
+// cross-namespace references are not allowed.
+func (b *MonoRepositoryStatus) MarkSourceAccessDenied(ctx context.Context, source string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionSourceReady, SourceAccessDeniedReason, "%s is in another namespace and cross-namespace references are not allowed", source)
+}
+
+// MarkSourceFailed records why the source could not be created or updated,
+// e.g. SourceConflictReason or InvalidSourceReason.
+func (b *MonoRepositoryStatus) MarkSourceFailed(ctx context.Context, reason string, source string, err error) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionSourceReady, reason, "unable to reconcile %s: %s", source, err.Error())
+}
+
+func (b *MonoRepositoryStatus) MarkDownloaded(ctx context.Context, revision string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositoryConditionArtifactDownloaded, MonoRepositorySucceededReason, "Downloaded artifact for revision %s", revision)
+}
+
+// MarkDownloadFailed records why the artifact could not be downloaded, the
+// reason is DownloadFailedReason or ExtractFailedReason.
+func (b *MonoRepositoryStatus) MarkDownloadFailed(ctx context.Context, reason string, err error) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionArtifactDownloaded, reason, err.Error())
+}
+
+func (b *MonoRepositoryStatus) MarkFiltered(ctx context.Context, count int, checksum string) {
+	containerCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositoryConditionArtifactFiltered, MonoRepositorySucceededReason, "Selected %d files with checksum %s", count, checksum)
+}
+
+// MarkFilterFailed records why the files of the artifact could not be filtered,
+// e.g. HashFailedReason, IncludeFileNotFoundReason or NoFilesMatchedReason.
+func (b *MonoRepositoryStatus) MarkFilterFailed(ctx context.Context, reason string, err error) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionArtifactFiltered, reason, err.Error())
+}
+
+func (b *MonoRepositoryStatus) MarkPublishFailed(ctx context.Context, err error) {
+	containerCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositoryConditionArtifactPublished, PublishFailedReason, err.Error())
+}
+
+// MarkReady marks the filtered artifact as published, the MonoRepository is
+// Ready once every other condition is also True.
 func (b *MonoRepositoryStatus) MarkReady(ctx context.Context, checksum string) {
-	containerCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositoryConditionReady, MonoRepositorySucceededReason, "Repository has been successfully filtered with checksum %s", checksum)
+	containerCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositoryConditionArtifactPublished, MonoRepositorySucceededReason, "Repository has been successfully filtered with checksum %s", checksum)
 }
@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	"time"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+// MonoRepository implements the Flux Source contract so that the filtered
+// artifact can be consumed in the same way as the artifact of a GitRepository.
+var _ apiv1.Source = &MonoRepository{}
+
+// GetArtifact returns the latest filtered artifact, nil if there is none.
+func (in *MonoRepository) GetArtifact() *apiv1.Artifact {
+	a := in.Status.Artifact
+	if a == nil {
+		return nil
+	}
+	return &apiv1.Artifact{
+		Path:           a.Path,
+		URL:            a.URL,
+		Revision:       a.Revision,
+		Digest:         a.Digest,
+		LastUpdateTime: a.LastUpdateTime,
+		Size:           a.Size,
+		Metadata:       a.Metadata,
+	}
+}
+
+// GetRequeueAfter returns the interval of the source created by the
+// MonoRepository. A referenced source is watched rather than polled, so zero is
+// returned when SourceRef is used.
+func (in *MonoRepository) GetRequeueAfter() time.Duration {
+	switch {
+	case in.Spec.GitRepository != nil:
+		return in.Spec.GitRepository.Interval.Duration
+	case in.Spec.OCIRepository != nil:
+		return in.Spec.OCIRepository.Interval.Duration
+	case in.Spec.Bucket != nil:
+		return in.Spec.Bucket.Interval.Duration
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,49 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMonoRepositorySource(t *testing.T) {
+	var src apiv1.Source = &MonoRepository{}
+	assert.Nil(t, src.GetArtifact())
+	assert.Equal(t, time.Duration(0), src.GetRequeueAfter())
+
+	size := int64(29)
+	now := metav1.NewTime(time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC))
+	src = &MonoRepository{
+		Spec: MonoRepositorySpec{
+			GitRepository: &v1beta2.GitRepositorySpec{
+				Interval: metav1.Duration{Duration: 5 * time.Minute},
+			},
+		},
+		Status: MonoRepositoryStatus{
+			Artifact: &Artifact{
+				Path:           "monorepository/dev/mono-repository/e3b0c442.tar.gz",
+				URL:            "http://localhost:9090/monorepository/dev/mono-repository/e3b0c442.tar.gz",
+				Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+				Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+				Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+				LastUpdateTime: now,
+				Size:           &size,
+			},
+		},
+	}
+
+	assert.Equal(t, 5*time.Minute, src.GetRequeueAfter())
+	assert.Equal(t, &apiv1.Artifact{
+		Path:           "monorepository/dev/mono-repository/e3b0c442.tar.gz",
+		URL:            "http://localhost:9090/monorepository/dev/mono-repository/e3b0c442.tar.gz",
+		Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+		Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+		LastUpdateTime: now,
+		Size:           &size,
+	}, src.GetArtifact())
+	assert.True(t, src.GetArtifact().HasRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"))
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
+
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/vmware-labs/reconciler-runtime/apis"
@@ -24,9 +26,175 @@ import (
 )
 
 // MonoRepositorySpec defines the structure of the mono repository.
+// +kubebuilder:validation:XValidation:rule="[has(self.gitRepository), has(self.ociRepository), has(self.bucket), has(self.sourceRef)].filter(x, x).size() == 1",message="exactly one of gitRepository, ociRepository, bucket or sourceRef must be specified"
 type MonoRepositorySpec struct {
-	GitRepository v1beta2.GitRepositorySpec `json:"gitRepository"`
-	Include       string                    `json:"include"`
+	// GitRepository is the spec of the GitRepository that is created to fetch the
+	// mono repository.
+	// +optional
+	GitRepository *v1beta2.GitRepositorySpec `json:"gitRepository,omitempty"`
+
+	// OCIRepository is the spec of the OCIRepository that is created to fetch the
+	// mono repository, e.g. an artifact pushed with flux push artifact.
+	// +optional
+	OCIRepository *v1beta2.OCIRepositorySpec `json:"ociRepository,omitempty"`
+
+	// Bucket is the spec of the Bucket that is created to fetch the mono
+	// repository from an S3 compatible storage.
+	// +optional
+	Bucket *v1beta2.BucketSpec `json:"bucket,omitempty"`
+
+	// SourceRef references an existing GitRepository, OCIRepository or Bucket
+	// that is watched, but not owned, by the MonoRepository. This allows many
+	// MonoRepositories to share a single clone of the mono repository.
+	// +optional
+	SourceRef *SourceReference `json:"sourceRef,omitempty"`
+
+	// Include is a gitignore style list of the files used to calculate the
+	// checksum, exclusions are expressed as ! negations and ordering matters.
+	// +optional
+	Include string `json:"include,omitempty"`
+
+	// IncludeFrom is the path of a file within the artifact, e.g.
+	// services/foo/.monorepo-include, holding additional gitignore style rules
+	// that are appended to Include. Patterns are relative to the root of the
+	// artifact.
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:XValidation:rule="!self.split('/').exists(p, p == '..')",message="includeFrom must be within the artifact"
+	IncludeFrom string `json:"includeFrom,omitempty"`
+
+	// Includes is a list of gitignore style patterns, a file is selected when
+	// it matches Include or any of these patterns.
+	// +optional
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.size() <= 1024)",message="includes must be at most 1024 characters long"
+	// +kubebuilder:validation:XValidation:rule="self.all(p, !p.startsWith('!'))",message="includes must not be negated, use exclude instead"
+	Includes []string `json:"includes,omitempty"`
+
+	// Exclude is a list of gitignore style patterns, a file matching any of
+	// these patterns is never selected, regardless of the include rules.
+	// +optional
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.size() <= 1024)",message="exclude must be at most 1024 characters long"
+	// +kubebuilder:validation:XValidation:rule="self.all(p, !p.startsWith('!'))",message="exclude must not be negated"
+	Exclude []string `json:"exclude,omitempty"`
+
+	// EmptyMatchPolicy is applied when the include rules do not select any file
+	// of the artifact. Fail marks the MonoRepository as not ready, Warn emits a
+	// Warning event and publishes the empty artifact, and Allow publishes it
+	// silently. Defaults to Warn.
+	// +optional
+	// +kubebuilder:validation:Enum=Fail;Warn;Allow
+	EmptyMatchPolicy EmptyMatchPolicy `json:"emptyMatchPolicy,omitempty"`
+
+	// Components are additional named outputs of the mono repository, each
+	// component is filtered with its own rules from the same download and has
+	// an independent checksum and artifact.
+	// +optional
+	// +kubebuilder:validation:MaxProperties=64
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.size() <= 63 && k.matches('^[a-z0-9]([-a-z0-9]*[a-z0-9])?$'))",message="component names must be at most 63 lowercase alphanumeric characters or '-'"
+	Components map[string]Component `json:"components,omitempty"`
+
+	// Notify configures the notifications that are sent whenever the filtered
+	// artifact changes.
+	// +optional
+	Notify *Notify `json:"notify,omitempty"`
+}
+
+// EmptyMatchPolicy is the policy applied when the include rules do not select
+// any file.
+type EmptyMatchPolicy string
+
+const (
+	EmptyMatchPolicyFail  EmptyMatchPolicy = "Fail"
+	EmptyMatchPolicyWarn  EmptyMatchPolicy = "Warn"
+	EmptyMatchPolicyAllow EmptyMatchPolicy = "Allow"
+)
+
+// Component holds the rules that select the files of a named output.
+type Component struct {
+	// Include is a gitignore style list of the files used to calculate the
+	// checksum of the component.
+	// +optional
+	Include string `json:"include,omitempty"`
+
+	// Includes is a list of gitignore style patterns, a file is selected when
+	// it matches Include or any of these patterns.
+	// +optional
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.size() <= 1024)",message="includes must be at most 1024 characters long"
+	// +kubebuilder:validation:XValidation:rule="self.all(p, !p.startsWith('!'))",message="includes must not be negated, use exclude instead"
+	Includes []string `json:"includes,omitempty"`
+
+	// Exclude is a list of gitignore style patterns, a file matching any of
+	// these patterns is never selected.
+	// +optional
+	// +kubebuilder:validation:MaxItems=256
+	// +kubebuilder:validation:XValidation:rule="self.all(p, p.size() <= 1024)",message="exclude must be at most 1024 characters long"
+	// +kubebuilder:validation:XValidation:rule="self.all(p, !p.startsWith('!'))",message="exclude must not be negated"
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Notify configures where change notifications are sent.
+type Notify struct {
+	// FluxEventsAddress is the events endpoint of the Flux
+	// notification-controller, e.g.
+	// http://notification-controller.flux-system.svc.cluster.local./
+	// +optional
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	FluxEventsAddress string `json:"fluxEventsAddress,omitempty"`
+
+	// Webhooks are posted a JSON payload describing each change.
+	// +optional
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+}
+
+// Webhook is an HTTP endpoint that receives change notifications.
+type Webhook struct {
+	// URL the payload is posted to.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	URL string `json:"url"`
+
+	// SecretRef references a Secret, in the namespace of the MonoRepository,
+	// whose token key signs the payload with HMAC-SHA256. The signature is sent
+	// in the X-Signature header as sha256=<hex>.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// Validate returns an error unless exactly one source has been specified.
+func (s *MonoRepositorySpec) Validate() error {
+	count := 0
+	if s.GitRepository != nil {
+		count++
+	}
+	if s.OCIRepository != nil {
+		count++
+	}
+	if s.Bucket != nil {
+		count++
+	}
+	if s.SourceRef != nil {
+		count++
+	}
+	if count != 1 {
+		return errors.New("exactly one of gitRepository, ociRepository, bucket or sourceRef must be specified")
+	}
+	return nil
+}
+
+// SourceReference is a reference to an existing Flux source.
+type SourceReference struct {
+	// Kind of the referent.
+	// +kubebuilder:validation:Enum=GitRepository;OCIRepository;Bucket
+	Kind string `json:"kind"`
+
+	// Name of the referent.
+	Name string `json:"name"`
+
+	// Namespace of the referent, defaults to the namespace of the MonoRepository.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // MonoRepositoryStatus defines the observed state of MonoRepository.
@@ -43,19 +211,102 @@ type MonoRepositoryStatus struct {
 	// +optional
 	Artifact *Artifact `json:"artifact,omitempty"`
 
-	// ObservedInclude is the observed list of GitRepository resources used to
-	// calculate the checksum for this artifact
+	// ObservedInclude is the effective include rules used to calculate the
+	// checksum for this artifact, annotated with where each rule came from
 	// +optional
 	ObservedInclude string `json:"observedInclude,omitempty"`
 
-	// ObservedFileList is the file list used to
-	// calculate the checksum for this artifact
+	// ObservedFileList is a preview of the file list used to calculate the
+	// checksum for this artifact, holding at most 100 paths. The complete list
+	// is in the file manifest.
 	// +optional
 	ObservedFileList string `json:"observedFileList,omitempty"`
 
+	// FileManifest references the manifest of the files used to calculate the
+	// checksum for this artifact.
+	// +optional
+	FileManifest *FileManifest `json:"fileManifest,omitempty"`
+
+	// UnmatchedIncludes lists the include rules, and includes patterns, that
+	// did not match any file of the artifact.
+	// +optional
+	UnmatchedIncludes []string `json:"unmatchedIncludes,omitempty"`
+
+	// ChangedFiles summarises the files that differ between the previous and
+	// the current artifact.
+	// +optional
+	ChangedFiles *ChangedFiles `json:"changedFiles,omitempty"`
+
+	// NotifiedChecksum is the checksum of the artifact the last change
+	// notifications were sent for.
+	// +optional
+	NotifiedChecksum string `json:"notifiedChecksum,omitempty"`
+
+	// Components holds the observed state of each component, keyed by name.
+	// +optional
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
+// ComponentStatus is the observed state of a component.
+type ComponentStatus struct {
+	// Artifact represents the filtered files of the component.
+	// +optional
+	Artifact *Artifact `json:"artifact,omitempty"`
+
+	// ObservedFileList is a preview of the file list used to calculate the
+	// checksum of the component, holding at most 100 paths.
+	// +optional
+	ObservedFileList string `json:"observedFileList,omitempty"`
+
+	// FileManifest references the manifest of the files used to calculate the
+	// checksum of the component.
+	// +optional
+	FileManifest *FileManifest `json:"fileManifest,omitempty"`
+}
+
+// FileManifest references the manifest stored alongside an artifact, it lists
+// each filtered file as a "<sha256>  <path>" line in the dirhash summary format.
+type FileManifest struct {
+	// Count is the number of files in the manifest.
+	Count int `json:"count"`
+
+	// Digest is the sha256 digest of the manifest.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Path is the relative file path of the manifest in the artifact storage.
+	Path string `json:"path"`
+
+	// URL is the HTTP address the manifest is served from.
+	URL string `json:"url"`
+}
+
+// ChangedFiles lists the paths that have been added, removed or modified in the
+// filtered file list. The lists are bounded, Truncated is set when they hold
+// fewer paths than Count.
+type ChangedFiles struct {
+	// Count is the total number of added, removed and modified files.
+	Count int `json:"count"`
+
+	// Added is the list of files that have been added.
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Removed is the list of files that have been removed.
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// Modified is the list of files whose content has changed.
+	// +optional
+	Modified []string `json:"modified,omitempty"`
+
+	// Truncated is true when not every changed file is listed.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
 // Artifact represents the output of a Source reconciliation.
 type Artifact struct {
 	// Path is the relative file path of the Artifact. It can be used to locate
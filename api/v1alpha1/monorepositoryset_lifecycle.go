@@ -0,0 +1,76 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vmware-labs/reconciler-runtime/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	MonoRepositorySetConditionReady = apis.ConditionReady
+	// MonoRepositorySetConditionSourceReady is True when the source the
+	// directories are generated from is ready with an artifact.
+	MonoRepositorySetConditionSourceReady = "SourceReady"
+
+	MonoRepositorySetSucceededReason = "Succeeded"
+	MonoRepositorySetFailedReason    = "Failed"
+)
+
+var setCondSet = apis.NewLivingConditionSetWithHappyReason(
+	MonoRepositorySetSucceededReason,
+	MonoRepositorySetConditionSourceReady,
+)
+
+func (b *MonoRepositorySetStatus) MarkFailed(ctx context.Context, err error) {
+	setCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositorySetConditionReady, MonoRepositorySetFailedReason, err.Error())
+}
+
+// MarkReady marks the set as ready once its MonoRepositories have been
+// generated from a ready source. Ready is set directly, rather than derived
+// from SourceReady, so that it reports the number of MonoRepositories.
+func (b *MonoRepositorySetStatus) MarkReady(ctx context.Context, count int) {
+	setCondSet.ManageWithContext(ctx, b).SetCondition(metav1.Condition{
+		Type:    MonoRepositorySetConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  MonoRepositorySetSucceededReason,
+		Message: fmt.Sprintf("Generated %d MonoRepositories", count),
+	})
+}
+
+func (b *MonoRepositorySetStatus) MarkSourceReady(ctx context.Context, source string, revision string) {
+	setCondSet.ManageWithContext(ctx, b).MarkTrue(MonoRepositorySetConditionSourceReady, MonoRepositorySetSucceededReason, "%s is ready with revision %s", source, revision)
+}
+
+// MarkSourceNotReady reflects the Ready condition of the source, a source that
+// has failed is False while a source that is still progressing is Unknown. The
+// existing MonoRepositories are kept until the source is ready again.
+func (b *MonoRepositorySetStatus) MarkSourceNotReady(ctx context.Context, source string, ready *metav1.Condition) {
+	switch {
+	case ready != nil && ready.Status == metav1.ConditionFalse:
+		setCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositorySetConditionSourceReady, SourceNotReadyReason, "%s is not ready: %s", source, ready.Message)
+	case ready != nil && ready.Status == metav1.ConditionTrue:
+		setCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositorySetConditionSourceReady, SourceNotReadyReason, "%s does not have an artifact", source)
+	case ready != nil && ready.Message != "":
+		setCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositorySetConditionSourceReady, SourceNotReadyReason, "waiting for %s to become ready: %s", source, ready.Message)
+	default:
+		setCondSet.ManageWithContext(ctx, b).MarkUnknown(MonoRepositorySetConditionSourceReady, SourceNotReadyReason, "waiting for %s to become ready", source)
+	}
+}
+
+func (b *MonoRepositorySetStatus) MarkSourceNotFound(ctx context.Context, source string) {
+	setCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositorySetConditionSourceReady, SourceNotFoundReason, "%s not found", source)
+}
+
+// MarkSourceOutOfScope records that the source is in a namespace the controller
+// does not watch, so it can neither be read nor tracked.
+func (b *MonoRepositorySetStatus) MarkSourceOutOfScope(ctx context.Context, source string) {
+	setCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositorySetConditionSourceReady, SourceOutOfScopeReason, "%s is in a namespace that is not watched by the controller", source)
+}
+
+// MarkSourceAccessDenied records that the source is in another namespace while
+// cross-namespace references are not allowed.
+func (b *MonoRepositorySetStatus) MarkSourceAccessDenied(ctx context.Context, source string) {
+	setCondSet.ManageWithContext(ctx, b).MarkFalse(MonoRepositorySetConditionSourceReady, SourceAccessDeniedReason, "%s is in another namespace and cross-namespace references are not allowed", source)
+}
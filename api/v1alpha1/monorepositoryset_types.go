@@ -0,0 +1,125 @@
+/*
+Copyright 2023 VMware Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/vmware-labs/reconciler-runtime/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MonoRepositorySetSpec defines the MonoRepositories that are generated from the
+// directories of a mono repository.
+type MonoRepositorySetSpec struct {
+	// SourceRef references the GitRepository, OCIRepository or Bucket holding the
+	// mono repository, it is shared by every generated MonoRepository.
+	SourceRef SourceReference `json:"sourceRef"`
+
+	// Generator selects the directories a MonoRepository is generated for.
+	Generator DirectoryGenerator `json:"generator"`
+
+	// Template describes the MonoRepository generated for each directory.
+	Template MonoRepositoryTemplate `json:"template"`
+}
+
+// DirectoryGenerator selects directories within the artifact.
+type DirectoryGenerator struct {
+	// Directories is a glob matched against the directories of the artifact,
+	// e.g. services/*.
+	Directories string `json:"directories"`
+
+	// Containing restricts the directories to those holding this file, e.g.
+	// Dockerfile.
+	// +optional
+	Containing string `json:"containing,omitempty"`
+}
+
+// MonoRepositoryTemplate describes a generated MonoRepository. The include rules,
+// label and annotation values are Go templates, {{ .Path }} is replaced with the
+// path of the directory and {{ .Name }} with its base name.
+type MonoRepositoryTemplate struct {
+	// Labels are added to each generated MonoRepository.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to each generated MonoRepository.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Include is a templated, gitignore style, list of the files used to
+	// calculate the checksum.
+	// +optional
+	Include string `json:"include,omitempty"`
+
+	// IncludeFrom is the templated path of a file within the artifact holding
+	// additional include rules.
+	// +optional
+	IncludeFrom string `json:"includeFrom,omitempty"`
+
+	// Includes is a list of templated gitignore style patterns.
+	// +optional
+	Includes []string `json:"includes,omitempty"`
+
+	// Exclude is a list of templated gitignore style patterns.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// MonoRepositorySetStatus defines the observed state of MonoRepositorySet.
+type MonoRepositorySetStatus struct {
+	apis.Status `json:",inline"`
+
+	// Directories is the list of directories a MonoRepository has been
+	// generated for.
+	// +optional
+	Directories []string `json:"directories,omitempty"`
+
+	// ObservedRevision is the revision of the artifact the directories were
+	// selected from.
+	// +optional
+	ObservedRevision string `json:"observedRevision,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=monoset
+//+kubebuilder:printcolumn:name="Source Ref",type="string",JSONPath=`.spec.sourceRef.name`
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+//+kubebuilder:printcolumn:name="Revision",type="string",JSONPath=".status.observedRevision",description=""
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
+//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+
+// MonoRepositorySet is the Schema for the mono repository set API.
+type MonoRepositorySet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MonoRepositorySetSpec   `json:"spec,omitempty"`
+	Status MonoRepositorySetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MonoRepositorySetList contains a list of MonoRepositorySet.
+type MonoRepositorySetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MonoRepositorySet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MonoRepositorySet{}, &MonoRepositorySetList{})
+}
@@ -19,18 +19,25 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"net"
 	"os"
 	"time"
 
+	"github.com/garethjevans/monorepository-controller/internal/config"
+	"github.com/garethjevans/monorepository-controller/internal/defaulting"
 	"github.com/garethjevans/monorepository-controller/internal/integrity"
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
 
 	v1 "github.com/fluxcd/source-controller/api/v1"
 	"github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/garethjevans/monorepository-controller/internal/testcert"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/garethjevans/monorepository-controller/internal/cache"
 	"github.com/garethjevans/monorepository-controller/internal/controller"
 	"github.com/vmware-labs/reconciler-runtime/reconcilers"
 
@@ -70,6 +77,10 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var webhookCertDir string
+	var storageAddr string
+	var storageAdvAddr string
+	var cloudEventsSink string
+	var cloudEventsMode string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -77,6 +88,13 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "", "Directory container certificates for the webhook server.")
+	flag.StringVar(&storageAddr, "storage-addr", ":9090", "The address the artifact server binds to.")
+	flag.StringVar(&storageAdvAddr, "storage-adv-addr", "", "The advertised address of the artifact server, defaults to the hostname and port of --storage-addr.")
+	flag.StringVar(&cloudEventsSink, "cloudevents-sink", "", "The URL CloudEvents are sent to when a filtered artifact changes, e.g. a Knative broker. No CloudEvents are sent when empty.")
+	flag.StringVar(&cloudEventsMode, "cloudevents-mode", notify.BinaryMode, "The HTTP content mode of the CloudEvents, binary or structured.")
+
+	cfg := config.Default()
+	cfg.BindFlags(flag.CommandLine)
 
 	opts := zap.Options{
 		Development: true,
@@ -86,8 +104,29 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := cfg.Load(flag.CommandLine); err != nil {
+		setupLog.Error(err, "unable to load configuration", "file", cfg.File)
+		os.Exit(1)
+	}
+
+	cacheOpts, err := cfg.CacheOptions()
+	if err != nil {
+		setupLog.Error(err, "unable to configure cache")
+		os.Exit(1)
+	}
+
+	selector, err := cfg.LabelSelector()
+	if err != nil {
+		setupLog.Error(err, "unable to configure selector")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Cache:  cacheOpts,
+		Controller: ctrlconfig.Controller{
+			MaxConcurrentReconciles: cfg.MaxConcurrentReconciles,
+		},
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
@@ -112,7 +151,7 @@ func main() {
 		},
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "d0711f0b.garethjevans.org",
+		LeaderElectionID:       cfg.LeaderElectionID,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -132,14 +171,63 @@ func main() {
 
 	ctx := ctrl.SetupSignalHandler()
 
+	artifactStorage, err := storage.New(cfg.StoragePath, determineAdvStorageAddr(storageAddr, storageAdvAddr))
+	if err != nil {
+		setupLog.Error(err, "unable to create artifact storage", "path", cfg.StoragePath)
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(&storage.Server{Storage: artifactStorage, Addr: storageAddr}); err != nil {
+		setupLog.Error(err, "unable to add artifact server")
+		os.Exit(1)
+	}
+
+	artifactCache, err := cache.New(cfg.CachePath, cfg.TempDir, cfg.CacheSize)
+	if err != nil {
+		setupLog.Error(err, "unable to create artifact cache", "path", cfg.CachePath)
+		os.Exit(1)
+	}
+
+	cloudEvents, err := notify.NewCloudEventsSink(cloudEventsSink, cloudEventsMode)
+	if err != nil {
+		setupLog.Error(err, "unable to create CloudEvents sink", "sink", cloudEventsSink)
+		os.Exit(1)
+	}
+
 	if err = controller.NewMonoRepositoryReconciler(
-		reconcilers.NewConfig(mgr, &v1alpha1.MonoRepository{}, 10*time.Hour),
+		reconcilers.NewConfig(mgr, &v1alpha1.MonoRepository{}, cfg.SyncPeriod.Duration),
+		controller.Options{
+			Storage:                 artifactStorage,
+			Cache:                   artifactCache,
+			TempDir:                 cfg.TempDir,
+			WatchNamespaces:         cfg.WatchNamespaces,
+			AllowCrossNamespaceRefs: cfg.AllowCrossNamespaceRefs,
+			Selector:                selector,
+			UnchangedEvents:         controller.NewEventLimiter(time.Hour),
+			CloudEvents:             cloudEvents,
+		},
 	).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MonoRepository")
 		os.Exit(1)
 	}
 
+	if err = controller.NewMonoRepositorySetReconciler(
+		reconcilers.NewConfig(mgr, &v1alpha1.MonoRepositorySet{}, cfg.SyncPeriod.Duration),
+		controller.Options{
+			Storage:                 artifactStorage,
+			Cache:                   artifactCache,
+			TempDir:                 cfg.TempDir,
+			WatchNamespaces:         cfg.WatchNamespaces,
+			AllowCrossNamespaceRefs: cfg.AllowCrossNamespaceRefs,
+			Selector:                selector,
+		},
+	).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MonoRepositorySet")
+		os.Exit(1)
+	}
+
 	integrity.RegisterReferentialIntegrityWebhooks(mgr)
+	defaulting.RegisterDefaultingWebhooks(mgr)
 
 	//+kubebuilder:scaffold:builder
 
@@ -158,3 +246,26 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// determineAdvStorageAddr returns the address the artifact server is advertised
+// on, falling back to the hostname of this pod when no address is given.
+func determineAdvStorageAddr(storageAddr, storageAdvAddr string) string {
+	if storageAdvAddr != "" {
+		return storageAdvAddr
+	}
+
+	host, port, err := net.SplitHostPort(storageAddr)
+	if err != nil {
+		setupLog.Error(err, "unable to parse storage address", "addr", storageAddr)
+		os.Exit(1)
+	}
+	switch host {
+	case "", "0.0.0.0":
+		host, err = os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "0.0.0.0 specified in --storage-addr but hostname is invalid")
+			os.Exit(1)
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
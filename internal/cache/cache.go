@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache holds extracted source artifacts on the local file system, keyed by the
+// digest of the artifact, so that MonoRepositories pointing at the same upstream
+// revision share a single download and extraction.
+//
+// Entries are reference counted while in use and evicted, least recently used
+// first, once the total size of the unused entries exceeds the maximum size.
+// Entries in use are never evicted and do not count towards the maximum size,
+// so the cache may hold more than the maximum size while they are in use.
+type Cache struct {
+	dir     string
+	tempDir string
+	maxSize int64
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	lru     *list.List
+	size    int64
+	unused  int64
+}
+
+type entry struct {
+	key  string
+	dir  string
+	size int64
+	refs int
+	elem *list.Element
+
+	// done is closed once the entry has been filled, err holds the result.
+	done chan struct{}
+	err  error
+}
+
+// New creates a Cache rooted at dir that holds up to maxSize bytes of unused
+// entries. Any content left in dir by a previous process is removed. A maxSize of
+// zero or less disables caching, each Get is then filled into a temporary
+// directory within tempDir, or the system temporary directory when empty, that
+// is removed on release.
+func New(dir string, tempDir string, maxSize int64) (*Cache, error) {
+	if maxSize > 0 {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Cache{
+		dir:     dir,
+		tempDir: tempDir,
+		maxSize: maxSize,
+		entries: map[string]*entry{},
+		lru:     list.New(),
+	}, nil
+}
+
+// Get returns the directory holding the content for key, calling fill to populate
+// an empty directory if the key is not present. Concurrent calls for the same key
+// wait for a single fill. The returned release function must be called once the
+// directory is no longer used, the directory must not be modified.
+func (c *Cache) Get(key string, fill func(dir string) error) (string, func(), error) {
+	if c == nil {
+		return uncached("", fill)
+	}
+	if c.maxSize <= 0 {
+		return uncached(c.tempDir, fill)
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if e.refs == 0 {
+			c.unused -= e.size
+		}
+		e.refs++
+		c.lru.MoveToFront(e.elem)
+		c.mu.Unlock()
+
+		<-e.done
+		if e.err != nil {
+			c.release(e)
+			return "", nil, e.err
+		}
+		return e.dir, c.releaseFunc(e), nil
+	}
+
+	e := &entry{
+		key:  key,
+		dir:  filepath.Join(c.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(key)))),
+		refs: 1,
+		done: make(chan struct{}),
+	}
+	e.elem = c.lru.PushFront(e)
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.err = populate(e.dir, fill)
+	if e.err == nil {
+		e.size, e.err = dirSize(e.dir)
+	}
+
+	c.mu.Lock()
+	if e.err != nil {
+		c.remove(e)
+	} else {
+		c.size += e.size
+	}
+	close(e.done)
+	c.mu.Unlock()
+
+	if e.err != nil {
+		c.release(e)
+		return "", nil, e.err
+	}
+	return e.dir, c.releaseFunc(e), nil
+}
+
+// Enabled returns true if entries are retained once released.
+func (c *Cache) Enabled() bool {
+	return c != nil && c.maxSize > 0
+}
+
+// Size returns the total size in bytes of the entries held in the cache.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Len returns the number of entries held in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *Cache) releaseFunc(e *entry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.release(e)
+		})
+	}
+}
+
+func (c *Cache) release(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.refs--
+	if e.refs == 0 && e.elem != nil {
+		c.unused += e.size
+	}
+	c.evict()
+}
+
+// evict removes unused entries, least recently used first, until the unused
+// entries fit within the maximum size. The caller must hold the lock.
+func (c *Cache) evict() {
+	for el := c.lru.Back(); el != nil && c.unused > c.maxSize; {
+		prev := el.Prev()
+		if e := el.Value.(*entry); e.refs <= 0 {
+			c.remove(e)
+			c.size -= e.size
+			c.unused -= e.size
+			_ = os.RemoveAll(e.dir)
+		}
+		el = prev
+	}
+}
+
+// remove drops the entry from the index. The caller must hold the lock.
+func (c *Cache) remove(e *entry) {
+	if c.entries[e.key] == e {
+		delete(c.entries, e.key)
+	}
+	if e.elem != nil {
+		c.lru.Remove(e.elem)
+		e.elem = nil
+	}
+}
+
+// populate fills an empty dir, removing any partial content on failure.
+func populate(dir string, fill func(dir string) error) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := fill(dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+func uncached(tempDir string, fill func(dir string) error) (string, func(), error) {
+	dir, err := os.MkdirTemp(tempDir, "tmp")
+	if err != nil {
+		return "", nil, err
+	}
+	release := func() {
+		_ = os.RemoveAll(dir)
+	}
+	if err := fill(dir); err != nil {
+		release()
+		return "", nil, err
+	}
+	return dir, release, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size, err
+}
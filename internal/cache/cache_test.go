@@ -0,0 +1,151 @@
+package cache_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func write(size int) func(dir string) error {
+	return func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, "file.txt"), make([]byte, size), 0o644)
+	}
+}
+
+func TestGetSharesFill(t *testing.T) {
+	c, err := cache.New(t.TempDir(), "", 1024)
+	assert.NoError(t, err)
+
+	var fills int32
+	fill := func(dir string) error {
+		atomic.AddInt32(&fills, 1)
+		return write(10)(dir)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dir, release, err := c.Get("sha256:abc", fill)
+			assert.NoError(t, err)
+			defer release()
+			assert.FileExists(t, filepath.Join(dir, "file.txt"))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fills)
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, int64(10), c.Size())
+}
+
+func TestGetEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := cache.New(t.TempDir(), "", 25)
+	assert.NoError(t, err)
+
+	first, release, err := c.Get("first", write(10))
+	assert.NoError(t, err)
+	release()
+
+	_, release, err = c.Get("second", write(10))
+	assert.NoError(t, err)
+	release()
+
+	// touch first so that second is the least recently used
+	_, release, err = c.Get("first", write(10))
+	assert.NoError(t, err)
+	release()
+
+	third, release, err := c.Get("third", write(10))
+	assert.NoError(t, err)
+	release()
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int64(20), c.Size())
+	assert.DirExists(t, first)
+	assert.DirExists(t, third)
+}
+
+func TestGetKeepsEntriesInUse(t *testing.T) {
+	c, err := cache.New(t.TempDir(), "", 5)
+	assert.NoError(t, err)
+
+	dir, release, err := c.Get("large", write(10))
+	assert.NoError(t, err)
+
+	// the entry exceeds the maximum size but is still in use
+	assert.DirExists(t, dir)
+	assert.Equal(t, 1, c.Len())
+
+	release()
+	release()
+	assert.NoDirExists(t, dir)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, int64(0), c.Size())
+}
+
+func TestGetOnlyCountsUnusedEntries(t *testing.T) {
+	c, err := cache.New(t.TempDir(), "", 25)
+	assert.NoError(t, err)
+
+	large, releaseLarge, err := c.Get("large", write(30))
+	assert.NoError(t, err)
+
+	// the entry in use does not count towards the maximum size
+	first, release, err := c.Get("first", write(10))
+	assert.NoError(t, err)
+	release()
+
+	second, release, err := c.Get("second", write(10))
+	assert.NoError(t, err)
+	release()
+
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, int64(50), c.Size())
+	assert.DirExists(t, first)
+	assert.DirExists(t, second)
+
+	// once released the unused entries exceed the maximum size
+	releaseLarge()
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int64(20), c.Size())
+	assert.NoDirExists(t, large)
+}
+
+func TestGetFailedFillIsNotCached(t *testing.T) {
+	c, err := cache.New(t.TempDir(), "", 1024)
+	assert.NoError(t, err)
+
+	_, _, err = c.Get("key", func(dir string) error {
+		return errors.New("download failed")
+	})
+	assert.EqualError(t, err, "download failed")
+	assert.Equal(t, 0, c.Len())
+
+	_, release, err := c.Get("key", write(10))
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestGetDisabled(t *testing.T) {
+	tempDir := t.TempDir()
+	c, err := cache.New(t.TempDir(), tempDir, 0)
+	assert.NoError(t, err)
+
+	dir, release, err := c.Get("key", write(10))
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(dir, "file.txt"))
+	assert.Equal(t, tempDir, filepath.Dir(dir))
+
+	release()
+	assert.NoDirExists(t, dir)
+	assert.Equal(t, 0, c.Len())
+}
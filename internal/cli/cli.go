@@ -0,0 +1,136 @@
+// Package cli implements the monorepo command line, usable as a kubectl plugin
+// when installed as kubectl-monorepo, to evaluate include rules locally.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// command is a subcommand of the monorepo command line.
+type command struct {
+	usage       string
+	description string
+	// notes are printed after the description in the help of the command.
+	notes string
+	run   func(args []string, stdout, stderr io.Writer) error
+}
+
+// commands is populated in init as the commands refer back to it for their
+// usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"status": {
+			usage:       "status [flags] [name]",
+			description: "List the MonoRepositories in a namespace, or the observed files of one MonoRepository",
+			run:         status,
+		},
+		"test-include": {
+			usage:       "test-include [flags] <directory|tarball>",
+			description: "List the files selected by the include rules and the checksum the controller would compute",
+			notes:       sourceNotes,
+			run:         testInclude,
+		},
+		"diff": {
+			usage:       "diff [flags] <old directory|tarball> <new directory|tarball>",
+			description: "Report whether the checksum would change between two revisions and the files that differ",
+			notes:       sourceNotes,
+			run:         diff,
+		},
+		"explain": {
+			usage:       "explain [flags] <path>",
+			description: "Explain which include rules select or reject a path",
+			run:         explain,
+		},
+	}
+}
+
+// ErrUsage is returned when the arguments are invalid, the usage has already
+// been printed.
+var ErrUsage = errors.New("invalid arguments")
+
+// Run runs the subcommand named by the first argument.
+func Run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return ErrUsage
+		}
+		return nil
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	if err := cmd.run(args[1:], stdout, stderr); !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: monorepo <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].description)
+	}
+}
+
+// newFlagSet returns a flag set for the named command that reports errors to
+// stderr.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: monorepo %s\n\n%s.\n\n", commands[name].usage, commands[name].description)
+		if notes := commands[name].notes; notes != "" {
+			fmt.Fprintf(stderr, "%s\n\n", notes)
+		}
+		fmt.Fprintln(stderr, "Flags:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of the command, returning between min and max
+// positional arguments.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, ErrUsage
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		return nil, ErrUsage
+	}
+	return fs.Args(), nil
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
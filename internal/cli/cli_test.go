@@ -0,0 +1,186 @@
+package cli_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/cli"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var files = map[string]string{
+	"go.mod":                    "module example",
+	"services/foo/main.go":      "package main",
+	"services/foo/main_test.go": "package main",
+	"services/bar/main.go":      "package main",
+}
+
+// checkout writes files to a directory along with a .git directory.
+func checkout(t *testing.T) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		write(t, filepath.Join(dir, name), content)
+	}
+	write(t, filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/main")
+	return dir
+}
+
+// tarball writes files to a gzip compressed tarball.
+func tarball(t *testing.T) string {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	name := filepath.Join(t.TempDir(), "artifact.tar.gz")
+	assert.NoError(t, os.WriteFile(name, buf.Bytes(), 0o644))
+	return name
+}
+
+func write(t *testing.T, name string, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+	assert.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+}
+
+func run(t *testing.T, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := cli.Run(args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestTestInclude(t *testing.T) {
+	dir := checkout(t)
+
+	stdout, stderr, err := run(t, "test-include", "--include", "/services/foo\n!*_test.go", "--includes", "/docs", dir)
+	assert.NoError(t, err)
+
+	expected, err := util.HashFiles([]string{"services/foo/main.go"}, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "services/foo/main.go\n\n1 of 4 files selected\nchecksum: "+expected+"\n", stdout)
+	assert.Equal(t, "warning: rule \"/docs\" does not match any file\n", stderr)
+
+	// the checksum of the artifact is identical to that of the checkout
+	stdout, _, err = run(t, "test-include", "--quiet", "--include", "/services/foo\n!*_test.go", tarball(t))
+	assert.NoError(t, err)
+	assert.Equal(t, expected+"\n", stdout)
+}
+
+func TestTestIncludeFile(t *testing.T) {
+	dir := checkout(t)
+	rules := filepath.Join(t.TempDir(), "rules")
+	write(t, rules, "/services/bar\n")
+
+	stdout, _, err := run(t, "test-include", "--include", "/go.mod", "--include-file", rules, "--exclude", "go.*", dir)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "services/bar/main.go\n\n1 of 4 files selected\n"), stdout)
+}
+
+func TestTestIncludeIgnore(t *testing.T) {
+	dir := checkout(t)
+	write(t, filepath.Join(dir, ".github", "workflows", "build.yaml"), "on: push")
+	write(t, filepath.Join(dir, "services", "foo", "logo.png"), "png")
+	write(t, filepath.Join(dir, "services", ".sourceignore"), "bar/\n")
+	write(t, filepath.Join(dir, "services", "foo", "README.md"), "# foo")
+
+	// the files source-controller leaves out of the artifact are skipped
+	stdout, _, err := run(t, "test-include", "--include", "/*", dir)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "go.mod\nservices/.sourceignore\nservices/foo/README.md\nservices/foo/main.go\nservices/foo/main_test.go\n\n5 of 5 files selected\n"), stdout)
+
+	stdout, _, err = run(t, "test-include", "--include", "/*", "--ignore", "*.md\n*_test.go", dir)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "go.mod\nservices/.sourceignore\nservices/foo/main.go\n\n3 of 3 files selected\n"), stdout)
+
+	// the ignore rules may include a file excluded by default
+	stdout, _, err = run(t, "test-include", "--include", "*.png", "--ignore", "!*.png", dir)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "services/foo/logo.png\n\n1 of 6 files selected\n"), stdout)
+}
+
+func TestExplain(t *testing.T) {
+	stdout, _, err := run(t, "explain", "--include", "# services\n/services\n!*_test.go", "services/foo/main_test.go")
+	assert.NoError(t, err)
+	assert.Equal(t, `services/foo/main_test.go is not selected
+  include:2 "/services" selects
+  include:3 "!*_test.go" rejects
+`, stdout)
+
+	stdout, _, err = run(t, "explain", "--includes", "/services/foo", "--exclude", "*.md", "/services/foo/main.go")
+	assert.NoError(t, err)
+	assert.Equal(t, `services/foo/main.go is selected
+  includes:1 "/services/foo" selects
+`, stdout)
+
+	stdout, _, err = run(t, "explain", "--include", "/services", "go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, "go.mod is not selected\n  no rule matches this path\n", stdout)
+}
+
+func TestUsage(t *testing.T) {
+	_, stderr, err := run(t)
+	assert.ErrorIs(t, err, cli.ErrUsage)
+	assert.Contains(t, stderr, "test-include")
+
+	_, _, err = run(t, "explain")
+	assert.ErrorIs(t, err, cli.ErrUsage)
+
+	// the commands reading a source describe how a directory is read
+	_, stderr, err = run(t, "test-include", "--help")
+	assert.NoError(t, err)
+	assert.Contains(t, stderr, ".sourceignore")
+	assert.Contains(t, stderr, "-ignore")
+
+	_, _, err = run(t, "unknown")
+	assert.EqualError(t, err, `unknown command "unknown"`)
+
+	_, _, err = run(t, "explain", "--include", "[", "go.mod")
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	previous := checkout(t)
+	current := checkout(t)
+	write(t, filepath.Join(current, "services/foo/main.go"), "package main // changed")
+	write(t, filepath.Join(current, "services/foo/handler.go"), "package main")
+	write(t, filepath.Join(current, "services/bar/main.go"), "package main // changed")
+	assert.NoError(t, os.Remove(filepath.Join(current, "services/foo/main_test.go")))
+
+	stdout, _, err := run(t, "diff", "--include", "/services/foo", previous, current)
+	assert.NoError(t, err)
+
+	oldHash, err := util.HashFiles([]string{"services/foo/main.go", "services/foo/main_test.go"}, previous)
+	assert.NoError(t, err)
+	newHash, err := util.HashFiles([]string{"services/foo/handler.go", "services/foo/main.go"}, current)
+	assert.NoError(t, err)
+	assert.Equal(t, `checksum changed: `+oldHash+` -> `+newHash+`
+
+added (1):
+  + services/foo/handler.go
+
+removed (1):
+  - services/foo/main_test.go
+
+modified (1):
+  ~ services/foo/main.go
+`, stdout)
+
+	// a tarball can be compared with a checkout, changes outside the rules are ignored
+	stdout, _, err = run(t, "diff", "--exit-code", "--include", "/go.mod", tarball(t), current)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "checksum unchanged: h1:"), stdout)
+
+	_, _, err = run(t, "diff", "--exit-code", "--include", "/services/bar", previous, current)
+	assert.ErrorIs(t, err, cli.ErrChanged)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrChanged is returned by diff with --exit-code when the filtered checksum
+// would change.
+var ErrChanged = errors.New("filtered checksum changed")
+
+// diff compares the files selected by the include rules in two revisions of a
+// source, each a local directory or tarball, reporting whether the checksum
+// would change and which of the selected files were added, removed or
+// modified.
+func diff(args []string, stdout, stderr io.Writer) error {
+	var r rules
+	fs := newFlagSet("diff", stderr)
+	r.addFlags(fs)
+	ignore := addIgnoreFlag(fs)
+	exitCode := fs.Bool("exit-code", false, "exit with status 1 when the checksum would change")
+
+	positional, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	match, _, err := r.filter()
+	if err != nil {
+		return err
+	}
+
+	previous, _, err := loadManifest(positional[0], *ignore, match)
+	if err != nil {
+		return err
+	}
+	current, _, err := loadManifest(positional[1], *ignore, match)
+	if err != nil {
+		return err
+	}
+
+	oldHash, err := previous.Hash1()
+	if err != nil {
+		return err
+	}
+	newHash, err := current.Hash1()
+	if err != nil {
+		return err
+	}
+
+	if oldHash == newHash {
+		fmt.Fprintf(stdout, "checksum unchanged: %s\n", newHash)
+		return nil
+	}
+	fmt.Fprintf(stdout, "checksum changed: %s -> %s\n", oldHash, newHash)
+
+	added, removed, modified := current.Diff(previous)
+	for _, section := range []struct {
+		name  string
+		mark  string
+		files []string
+	}{
+		{"added", "+", added},
+		{"removed", "-", removed},
+		{"modified", "~", modified},
+	} {
+		if len(section.files) == 0 {
+			continue
+		}
+		fmt.Fprintf(stdout, "\n%s (%d):\n", section.name, len(section.files))
+		for _, file := range section.files {
+			fmt.Fprintf(stdout, "  %s %s\n", section.mark, file)
+		}
+	}
+
+	if *exitCode {
+		return ErrChanged
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// explain prints whether a path is selected by the include rules along with
+// every rule that matches it. When several rules match, the last include rule
+// wins and an exclude pattern always rejects the path.
+func explain(args []string, stdout, stderr io.Writer) error {
+	var r rules
+	fs := newFlagSet("explain", stderr)
+	r.addFlags(fs)
+
+	positional, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	match, include, err := r.filter()
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+positional[0]), "/")
+	verdict := "is not selected"
+	if match(name) {
+		verdict = "is selected"
+	}
+	fmt.Fprintf(stdout, "%s %s\n", name, verdict)
+
+	matches := r.matchingRules(include, name)
+	if len(matches) == 0 {
+		fmt.Fprintln(stdout, "  no rule matches this path")
+		return nil
+	}
+	for _, m := range matches {
+		fmt.Fprintf(stdout, "  %s\n", m)
+	}
+	return nil
+}
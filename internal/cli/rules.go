@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/garethjevans/monorepository-controller/internal/util"
+)
+
+// rules holds the include rules of a MonoRepository given on the command line.
+type rules struct {
+	include     string
+	includeFile string
+	includes    stringList
+	exclude     stringList
+}
+
+// addFlags registers the flags mirroring the include, includeFrom, includes
+// and exclude fields of a MonoRepository.
+func (r *rules) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&r.include, "include", "", "gitignore style include rules, as in spec.include")
+	fs.StringVar(&r.includeFile, "include-file", "", "local file holding include rules that are appended to --include, as with spec.includeFrom")
+	fs.Var(&r.includes, "includes", "gitignore style pattern selecting files, as in spec.includes (can be repeated)")
+	fs.Var(&r.exclude, "exclude", "gitignore style pattern rejecting files, as in spec.exclude (can be repeated)")
+}
+
+// effectiveInclude returns the include rules followed by those of the include
+// file, in the same way that the controller appends the rules of includeFrom.
+func (r *rules) effectiveInclude() (string, error) {
+	if r.includeFile == "" {
+		return r.include, nil
+	}
+
+	content, err := os.ReadFile(r.includeFile)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if r.include != "" {
+		b.WriteString(strings.TrimSuffix(r.include, "\n"))
+		b.WriteString("\n")
+	}
+	b.WriteString(strings.TrimSuffix(string(content), "\n"))
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// filter returns the function selecting files and the effective include rules.
+func (r *rules) filter() (func(name string) bool, string, error) {
+	include, err := r.effectiveInclude()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := util.ValidateInclude(include); err != nil {
+		return nil, "", err
+	}
+	return util.NewFilter(include, r.includes, r.exclude), include, nil
+}
+
+// ruleMatch is a rule that matches a path.
+type ruleMatch struct {
+	// field is the field of the MonoRepository holding the rule.
+	field string
+	// line is the 1-based line, or list index, of the rule within the field.
+	line int
+	rule string
+	// rejects is true when the rule is a negation or an exclude pattern.
+	rejects bool
+}
+
+func (m ruleMatch) String() string {
+	verb := "selects"
+	if m.rejects {
+		verb = "rejects"
+	}
+	return fmt.Sprintf("%s:%d %q %s", m.field, m.line, m.rule, verb)
+}
+
+// matchingRules returns, in the order they are evaluated, each rule that
+// matches name on its own.
+func (r *rules) matchingRules(include string, name string) []ruleMatch {
+	var matches []ruleMatch
+	for i, line := range strings.Split(include, "\n") {
+		rule := strings.TrimSpace(line)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(rule, "!")
+		if util.NewMatcher(strings.TrimPrefix(rule, "!"))(name) {
+			matches = append(matches, ruleMatch{field: "include", line: i + 1, rule: rule, rejects: negated})
+		}
+	}
+	for i, pattern := range r.includes {
+		if util.NewMatcher(pattern)(name) {
+			matches = append(matches, ruleMatch{field: "includes", line: i + 1, rule: pattern})
+		}
+	}
+	for i, pattern := range r.exclude {
+		if util.NewMatcher(pattern)(name) {
+			matches = append(matches, ruleMatch{field: "exclude", line: i + 1, rule: pattern, rejects: true})
+		}
+	}
+	return matches
+}
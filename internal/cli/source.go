@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/fluxcd/pkg/sourceignore"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+)
+
+// sourceNotes describes how the commands that read a source treat a local
+// directory, which is assumed to be a checkout archived by source-controller.
+const sourceNotes = `A directory is read as source-controller would archive it: version control files, the default
+exclusions of source-controller (images, archives and CI configuration) and the patterns of any
+.sourceignore files are skipped, followed by the --ignore rules. A tarball is read as is, as it is
+expected to be the artifact of a source.`
+
+// addIgnoreFlag registers the flag mirroring the ignore field of the source.
+func addIgnoreFlag(fs *flag.FlagSet) *string {
+	return fs.String("ignore", "", "gitignore style rules excluding files of a directory, as in the ignore field of the source")
+}
+
+// loadManifest hashes the files of a local directory, or a gzip compressed
+// tarball such as a downloaded source artifact, that are accepted by match. It
+// also returns the number of files that were considered. The files of a
+// directory that source-controller would exclude from the artifact, along with
+// those matching the ignore rules, are skipped.
+func loadManifest(source string, ignore string, match func(name string) bool) (util.Manifest, int, error) {
+	fi, err := os.Stat(source)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := 0
+	if fi.IsDir() {
+		var domain []string
+		patterns, err := sourceignore.LoadIgnorePatterns(source, domain)
+		if err != nil {
+			return nil, 0, err
+		}
+		patterns = append(patterns, sourceignore.ReadPatterns(strings.NewReader(ignore), domain)...)
+		ignored := sourceignore.NewDefaultMatcher(patterns, domain)
+
+		manifest, err := util.HashDir(source, func(name string) bool {
+			if ignored.Match(strings.Split(name, "/"), false) {
+				return false
+			}
+			total++
+			return match(name)
+		})
+		return manifest, total, err
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	manifest, err := util.HashTarGz(f, func(name string) bool {
+		total++
+		return match(name)
+	})
+	return manifest, total, err
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// statusOptions selects the MonoRepositories shown by status and how.
+type statusOptions struct {
+	namespace     string
+	allNamespaces bool
+	name          string
+	files         bool
+	tree          bool
+}
+
+// status lists the MonoRepositories in a namespace, or shows the observed file
+// list of a single MonoRepository.
+func status(args []string, stdout, stderr io.Writer) error {
+	var o statusOptions
+	var kubeconfig, kubecontext string
+	fs := newFlagSet("status", stderr)
+	fs.StringVar(&kubeconfig, "kubeconfig", "", "path to the kubeconfig file, defaults to $KUBECONFIG or ~/.kube/config")
+	fs.StringVar(&kubecontext, "context", "", "kubeconfig context to use")
+	fs.StringVar(&o.namespace, "namespace", "", "namespace of the MonoRepositories, defaults to that of the context")
+	fs.StringVar(&o.namespace, "n", "", "shorthand for --namespace")
+	fs.BoolVar(&o.allNamespaces, "all-namespaces", false, "list the MonoRepositories in every namespace")
+	fs.BoolVar(&o.allNamespaces, "A", false, "shorthand for --all-namespaces")
+	fs.BoolVar(&o.files, "files", false, "print the observed file list of the named MonoRepository")
+	fs.BoolVar(&o.tree, "tree", false, "print the observed file list of the named MonoRepository as a tree")
+
+	positional, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 1 {
+		o.name = positional[0]
+	}
+	if (o.files || o.tree) && o.name == "" {
+		fs.Usage()
+		return ErrUsage
+	}
+
+	loader := clientcmd.NewDefaultClientConfigLoadingRules()
+	loader.ExplicitPath = kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &clientcmd.ConfigOverrides{CurrentContext: kubecontext})
+	if o.namespace == "" {
+		namespace, _, err := config.Namespace()
+		if err != nil {
+			return err
+		}
+		o.namespace = namespace
+	}
+
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return err
+	}
+	c, err := client.New(restConfig, client.Options{Scheme: newScheme()})
+	if err != nil {
+		return err
+	}
+	return printStatus(context.Background(), c, o, stdout, stderr)
+}
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	return scheme
+}
+
+func printStatus(ctx context.Context, c client.Client, o statusOptions, stdout, stderr io.Writer) error {
+	var items []v1alpha1.MonoRepository
+	if o.name != "" {
+		repo := v1alpha1.MonoRepository{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: o.namespace, Name: o.name}, &repo); err != nil {
+			return err
+		}
+		items = append(items, repo)
+	} else {
+		list := v1alpha1.MonoRepositoryList{}
+		var opts []client.ListOption
+		if !o.allNamespaces {
+			opts = append(opts, client.InNamespace(o.namespace))
+		}
+		if err := c.List(ctx, &list, opts...); err != nil {
+			return err
+		}
+		items = list.Items
+	}
+
+	if o.files || o.tree {
+		repo := &items[0]
+		files := fileList(repo)
+		if o.tree {
+			printTree(stdout, files)
+		} else {
+			for _, file := range files {
+				fmt.Fprintln(stdout, file)
+			}
+		}
+		if count := fileCount(repo); count > len(files) {
+			fmt.Fprintf(stderr, "showing %d of %d files, the complete file manifest is served at %s\n", len(files), count, repo.Status.FileManifest.URL)
+		}
+		return nil
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(stdout, "No MonoRepositories found in %s namespace.\n", o.namespace)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	header := "NAME\tREVISION\tCHECKSUM\tLAST CHANGE\tFILES\tREADY\tREASON"
+	if o.allNamespaces {
+		header = "NAMESPACE\t" + header
+	}
+	fmt.Fprintln(w, header)
+	for i := range items {
+		repo := &items[i]
+		revision, checksum, changed := "-", "-", "-"
+		if a := repo.Status.Artifact; a != nil {
+			revision, checksum = a.Revision, a.Checksum
+			if !a.LastUpdateTime.IsZero() {
+				changed = a.LastUpdateTime.UTC().Format(time.RFC3339)
+			}
+		}
+		ready, reason := "Unknown", "-"
+		if cond := apimeta.FindStatusCondition(repo.Status.Conditions, v1alpha1.MonoRepositoryConditionReady); cond != nil {
+			ready, reason = string(cond.Status), cond.Reason
+		}
+
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%d\t%s\t%s", repo.Name, revision, checksum, changed, fileCount(repo), ready, reason)
+		if o.allNamespaces {
+			row = repo.Namespace + "\t" + row
+		}
+		fmt.Fprintln(w, row)
+	}
+	return w.Flush()
+}
+
+// fileCount returns the number of files used to calculate the checksum.
+func fileCount(repo *v1alpha1.MonoRepository) int {
+	if m := repo.Status.FileManifest; m != nil {
+		return m.Count
+	}
+	return len(fileList(repo))
+}
+
+// fileList returns the observed file list of the MonoRepository, which is a
+// preview when the MonoRepository has more files.
+func fileList(repo *v1alpha1.MonoRepository) []string {
+	if repo.Status.ObservedFileList == "" {
+		return nil
+	}
+	return strings.Split(repo.Status.ObservedFileList, "\n")
+}
+
+// printTree prints the slash separated paths as a directory tree.
+func printTree(w io.Writer, files []string) {
+	type node struct {
+		children map[string]*node
+	}
+	root := &node{children: map[string]*node{}}
+	for _, file := range files {
+		n := root
+		for _, part := range strings.Split(path.Clean(file), "/") {
+			child, ok := n.children[part]
+			if !ok {
+				child = &node{children: map[string]*node{}}
+				n.children[part] = child
+			}
+			n = child
+		}
+	}
+
+	var walk func(n *node, prefix string)
+	walk = func(n *node, prefix string) {
+		names := make([]string, 0, len(n.children))
+		for name := range n.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for i, name := range names {
+			branch, indent := "├── ", "│   "
+			if i == len(names)-1 {
+				branch, indent = "└── ", "    "
+			}
+			child := n.children[name]
+			if len(child.children) > 0 {
+				name += "/"
+			}
+			fmt.Fprintf(w, "%s%s%s\n", prefix, branch, name)
+			walk(child, prefix+indent)
+		}
+	}
+	fmt.Fprintln(w, ".")
+	walk(root, "")
+}
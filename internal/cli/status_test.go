@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPrintStatus(t *testing.T) {
+	ready := &v1alpha1.MonoRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "availability", Namespace: "dev"},
+		Status: v1alpha1.MonoRepositoryStatus{
+			Artifact: &v1alpha1.Artifact{
+				Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+				Checksum:       "h1:BARdBbUvae7sFv+t0UdjbEcZBgVvVJimNxi8kSCGURg=",
+				LastUpdateTime: metav1.NewTime(time.Date(2023, 5, 5, 10, 17, 23, 0, time.UTC)),
+			},
+			ObservedFileList: "pom.xml\navailability/pom.xml\navailability/src/main/App.java",
+			FileManifest: &v1alpha1.FileManifest{
+				Count: 120,
+				URL:   "http://localhost:9090/monorepository/dev/availability/artifact.manifest",
+			},
+		},
+	}
+	ready.Status.Conditions = []metav1.Condition{{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Succeeded"}}
+
+	pending := &v1alpha1.MonoRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "dev"},
+	}
+	other := &v1alpha1.MonoRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "prod"},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(ready, pending, other).Build()
+
+	var stderr bytes.Buffer
+	show := func(o statusOptions) string {
+		var out bytes.Buffer
+		stderr.Reset()
+		assert.NoError(t, printStatus(context.Background(), c, o, &out, &stderr))
+		return out.String()
+	}
+
+	assert.Equal(t, `NAME          REVISION                                            CHECKSUM                                         LAST CHANGE           FILES  READY    REASON
+availability  main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7  h1:BARdBbUvae7sFv+t0UdjbEcZBgVvVJimNxi8kSCGURg=  2023-05-05T10:17:23Z  120    True     Succeeded
+pending       -                                                   -                                                -                     0      Unknown  -
+`, show(statusOptions{namespace: "dev"}))
+
+	assert.Equal(t, `NAMESPACE  NAME   REVISION  CHECKSUM  LAST CHANGE  FILES  READY    REASON
+prod       other  -         -         -            0      Unknown  -
+`, show(statusOptions{namespace: "prod", name: "other", allNamespaces: true}))
+
+	assert.Equal(t, "No MonoRepositories found in staging namespace.\n", show(statusOptions{namespace: "staging"}))
+
+	assert.Equal(t, "pom.xml\navailability/pom.xml\navailability/src/main/App.java\n", show(statusOptions{namespace: "dev", name: "availability", files: true}))
+	assert.Equal(t, "showing 3 of 120 files, the complete file manifest is served at http://localhost:9090/monorepository/dev/availability/artifact.manifest\n", stderr.String())
+
+	assert.Equal(t, `.
+├── availability/
+│   ├── pom.xml
+│   └── src/
+│       └── main/
+│           └── App.java
+└── pom.xml
+`, show(statusOptions{namespace: "dev", name: "availability", tree: true}))
+
+	err := printStatus(context.Background(), c, statusOptions{namespace: "dev", name: "missing"}, &bytes.Buffer{}, &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/garethjevans/monorepository-controller/internal/util"
+)
+
+// testInclude prints the files of a directory or tarball that are selected by
+// the include rules, followed by the checksum the controller would compute.
+func testInclude(args []string, stdout, stderr io.Writer) error {
+	var r rules
+	fs := newFlagSet("test-include", stderr)
+	r.addFlags(fs)
+	ignore := addIgnoreFlag(fs)
+	quiet := fs.Bool("quiet", false, "only print the checksum")
+
+	positional, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	match, include, err := r.filter()
+	if err != nil {
+		return err
+	}
+
+	matches := util.NewRuleMatches(include, r.includes)
+	manifest, total, err := loadManifest(positional[0], *ignore, func(name string) bool {
+		matches.Observe(name)
+		return match(name)
+	})
+	if err != nil {
+		return err
+	}
+
+	hash, err := manifest.Hash1()
+	if err != nil {
+		return err
+	}
+
+	if *quiet {
+		fmt.Fprintln(stdout, hash)
+		return nil
+	}
+
+	files := manifest.Files()
+	for _, file := range files {
+		fmt.Fprintln(stdout, file)
+	}
+	fmt.Fprintf(stdout, "\n%d of %d files selected\nchecksum: %s\n", len(files), total, hash)
+
+	for _, rule := range matches.Unmatched() {
+		fmt.Fprintf(stderr, "warning: rule %q does not match any file\n", rule)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Config holds the settings of the controller manager that are tuned per
+// installation. Each setting is bound to a flag and may also be read from the
+// YAML file named by --config, a flag given on the command line overrides the
+// value from the file.
+type Config struct {
+	// SyncPeriod is the interval at which every watched resource is reconciled
+	// again, even when nothing has changed.
+	SyncPeriod metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// MaxConcurrentReconciles is the number of resources each controller
+	// reconciles at the same time.
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+
+	// WatchNamespaces limits the manager to the resources in these namespaces,
+	// every namespace is watched when empty.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// Selector is a label selector limiting the MonoRepositories and
+	// MonoRepositorySets handled by the manager, all are handled when empty.
+	Selector string `json:"selector,omitempty"`
+
+	// AllowCrossNamespaceRefs allows a sourceRef to a source in another
+	// namespace. It is off by default so that a tenant cannot republish the
+	// artifacts of the sources in the namespaces of other tenants.
+	AllowCrossNamespaceRefs bool `json:"allowCrossNamespaceRefs,omitempty"`
+
+	// LeaderElectionID is the name of the lease used for leader election, each
+	// manager handling a different set of resources needs its own.
+	LeaderElectionID string `json:"leaderElectionID,omitempty"`
+
+	// StoragePath is the local directory the filtered artifacts are stored in.
+	StoragePath string `json:"storagePath,omitempty"`
+
+	// CachePath is the local directory extracted source artifacts are cached in.
+	CachePath string `json:"cachePath,omitempty"`
+
+	// CacheSize is the maximum size in bytes of the unused extracted source
+	// artifacts kept in CachePath. Artifacts are streamed, without extracting
+	// them to disk, when zero.
+	CacheSize int64 `json:"cacheSize,omitempty"`
+
+	// TempDir is the local directory source artifacts are downloaded to before
+	// they are extracted, the system temporary directory is used when empty.
+	TempDir string `json:"tempDir,omitempty"`
+
+	// File is the path of the YAML file the settings were read from.
+	File string `json:"-"`
+}
+
+// Default returns the settings used when neither a flag nor the config file
+// sets a value.
+func Default() Config {
+	return Config{
+		SyncPeriod:              metav1.Duration{Duration: 10 * time.Hour},
+		MaxConcurrentReconciles: 1,
+		LeaderElectionID:        "d0711f0b.garethjevans.org",
+		StoragePath:             filepath.Join(os.TempDir(), "monorepository"),
+		CachePath:               filepath.Join(os.TempDir(), "monorepository-cache"),
+	}
+}
+
+// BindFlags defines a flag for each setting on fs, using the current values as
+// the defaults.
+func (c *Config) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.File, "config", c.File, "The path of a YAML file holding the settings of the manager, flags override the values in the file.")
+	fs.DurationVar(&c.SyncPeriod.Duration, "sync-period", c.SyncPeriod.Duration, "The interval at which every watched resource is reconciled again.")
+	fs.IntVar(&c.MaxConcurrentReconciles, "max-concurrent-reconciles", c.MaxConcurrentReconciles, "The number of resources each controller reconciles at the same time.")
+	fs.Var((*namespaces)(&c.WatchNamespaces), "watch-namespaces", "A comma separated list of the namespaces to watch, every namespace is watched when empty.")
+	fs.StringVar(&c.Selector, "selector", c.Selector, "A label selector limiting the MonoRepositories and MonoRepositorySets handled by this manager.")
+	fs.BoolVar(&c.AllowCrossNamespaceRefs, "allow-cross-namespace-refs", c.AllowCrossNamespaceRefs, "Allow a sourceRef to a source in another namespace.")
+	fs.StringVar(&c.LeaderElectionID, "leader-election-id", c.LeaderElectionID, "The name of the lease used for leader election.")
+	fs.StringVar(&c.StoragePath, "storage-path", c.StoragePath, "The local storage path for filtered artifacts.")
+	fs.StringVar(&c.CachePath, "cache-path", c.CachePath, "The local path extracted source artifacts are cached in.")
+	fs.Int64Var(&c.CacheSize, "cache-size", c.CacheSize, "The maximum size in bytes of unused extracted source artifacts to cache, artifacts are streamed without being extracted when 0.")
+	fs.StringVar(&c.TempDir, "temp-dir", c.TempDir, "The local path source artifacts are downloaded to before they are extracted, defaults to the system temporary directory.")
+}
+
+// Load reads the config file named by --config, once fs has been parsed, and
+// applies every flag set on the command line on top of it. The settings are
+// validated whether or not a file is used.
+func (c *Config) Load(fs *flag.FlagSet) error {
+	if c.File != "" {
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return err
+		}
+		loaded := Default()
+		if err := yaml.UnmarshalStrict(data, &loaded); err != nil {
+			return fmt.Errorf("unable to read config file %s: %w", c.File, err)
+		}
+
+		overrides := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+		loaded.BindFlags(overrides)
+		var setErr error
+		fs.Visit(func(f *flag.Flag) {
+			if overrides.Lookup(f.Name) == nil || setErr != nil {
+				return
+			}
+			setErr = overrides.Set(f.Name, f.Value.String())
+		})
+		if setErr != nil {
+			return setErr
+		}
+		*c = loaded
+	}
+	return c.Validate()
+}
+
+// Validate returns an error when a setting is out of range.
+func (c *Config) Validate() error {
+	if c.SyncPeriod.Duration <= 0 {
+		return fmt.Errorf("sync period must be positive, got %s", c.SyncPeriod.Duration)
+	}
+	if c.MaxConcurrentReconciles < 1 {
+		return fmt.Errorf("max concurrent reconciles must be at least 1, got %d", c.MaxConcurrentReconciles)
+	}
+	if c.CacheSize < 0 {
+		return fmt.Errorf("cache size must not be negative, got %d", c.CacheSize)
+	}
+	if c.LeaderElectionID == "" {
+		return errors.New("leader election id must not be empty")
+	}
+	if _, err := labels.Parse(c.Selector); err != nil {
+		return fmt.Errorf("invalid selector %q: %w", c.Selector, err)
+	}
+	return nil
+}
+
+// CacheOptions returns the options of the manager cache limiting the watched
+// resources to the configured namespaces and selector.
+func (c *Config) CacheOptions() (cache.Options, error) {
+	syncPeriod := c.SyncPeriod.Duration
+	opts := cache.Options{
+		SyncPeriod: &syncPeriod,
+	}
+
+	if len(c.WatchNamespaces) > 0 {
+		opts.DefaultNamespaces = map[string]cache.Config{}
+		for _, namespace := range c.WatchNamespaces {
+			opts.DefaultNamespaces[namespace] = cache.Config{}
+		}
+	}
+
+	if c.Selector != "" {
+		selector, err := c.LabelSelector()
+		if err != nil {
+			return cache.Options{}, err
+		}
+		opts.ByObject = map[client.Object]cache.ByObject{
+			&v1alpha1.MonoRepository{}:    {Label: selector},
+			&v1alpha1.MonoRepositorySet{}: {Label: selector},
+		}
+	}
+	return opts, nil
+}
+
+// LabelSelector returns the parsed selector, nil when every resource is handled.
+func (c *Config) LabelSelector() (labels.Selector, error) {
+	if c.Selector == "" {
+		return nil, nil
+	}
+	selector, err := labels.Parse(c.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", c.Selector, err)
+	}
+	return selector, nil
+}
+
+// namespaces is a flag holding a comma separated list of namespaces, setting
+// the flag replaces the list.
+type namespaces []string
+
+func (n *namespaces) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *namespaces) Set(value string) error {
+	*n = nil
+	for _, namespace := range strings.Split(value, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			*n = append(*n, namespace)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package config_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func load(t *testing.T, args ...string) (config.Config, error) {
+	c := config.Default()
+	fs := flag.NewFlagSet("manager", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.BindFlags(fs)
+	assert.NoError(t, fs.Parse(args))
+	err := c.Load(fs)
+	return c, err
+}
+
+func writeFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	return file
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := load(t)
+	assert.NoError(t, err)
+	assert.Equal(t, config.Default(), c)
+}
+
+func TestLoadFlags(t *testing.T) {
+	c, err := load(t,
+		"--sync-period=30m",
+		"--max-concurrent-reconciles=4",
+		"--watch-namespaces=dev, prod",
+		"--selector=shard=a",
+		"--temp-dir=/scratch",
+		"--cache-size=1024",
+		"--allow-cross-namespace-refs",
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, c.SyncPeriod.Duration)
+	assert.Equal(t, 4, c.MaxConcurrentReconciles)
+	assert.Equal(t, []string{"dev", "prod"}, c.WatchNamespaces)
+	assert.Equal(t, "shard=a", c.Selector)
+	assert.Equal(t, "/scratch", c.TempDir)
+	assert.Equal(t, int64(1024), c.CacheSize)
+	assert.True(t, c.AllowCrossNamespaceRefs)
+}
+
+func TestLoadFileWithOverrides(t *testing.T) {
+	file := writeFile(t, `syncPeriod: 1h
+maxConcurrentReconciles: 8
+watchNamespaces:
+- dev
+- prod
+selector: shard=a
+storagePath: /data
+cacheSize: 1073741824
+`)
+
+	c, err := load(t, "--config="+file, "--max-concurrent-reconciles=2", "--watch-namespaces=staging")
+	assert.NoError(t, err)
+	assert.Equal(t, file, c.File)
+	assert.Equal(t, time.Hour, c.SyncPeriod.Duration)
+	assert.Equal(t, 2, c.MaxConcurrentReconciles)
+	assert.Equal(t, []string{"staging"}, c.WatchNamespaces)
+	assert.Equal(t, "shard=a", c.Selector)
+	assert.Equal(t, "/data", c.StoragePath)
+	assert.Equal(t, int64(1<<30), c.CacheSize)
+	assert.Equal(t, config.Default().CachePath, c.CachePath)
+	assert.Equal(t, config.Default().LeaderElectionID, c.LeaderElectionID)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := load(t, "--max-concurrent-reconciles=0")
+	assert.EqualError(t, err, "max concurrent reconciles must be at least 1, got 0")
+
+	_, err = load(t, "--sync-period=0s")
+	assert.EqualError(t, err, "sync period must be positive, got 0s")
+
+	_, err = load(t, "--cache-size=-1")
+	assert.EqualError(t, err, "cache size must not be negative, got -1")
+
+	_, err = load(t, "--selector=shard in (a")
+	assert.ErrorContains(t, err, `invalid selector "shard in (a"`)
+
+	_, err = load(t, "--config="+writeFile(t, "syncPeriods: 1h\n"))
+	assert.ErrorContains(t, err, `unknown field "syncPeriods"`)
+
+	_, err = load(t, "--config="+filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestCacheOptions(t *testing.T) {
+	c := config.Default()
+	opts, err := c.CacheOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Hour, *opts.SyncPeriod)
+	assert.Nil(t, opts.DefaultNamespaces)
+	assert.Nil(t, opts.ByObject)
+
+	c.WatchNamespaces = []string{"dev", "prod"}
+	c.Selector = "shard=a"
+	opts, err = c.CacheOptions()
+	assert.NoError(t, err)
+	assert.Len(t, opts.DefaultNamespaces, 2)
+	assert.Contains(t, opts.DefaultNamespaces, "dev")
+	assert.Contains(t, opts.DefaultNamespaces, "prod")
+
+	assert.Len(t, opts.ByObject, 2)
+	for obj, byObject := range opts.ByObject {
+		switch obj.(type) {
+		case *v1alpha1.MonoRepository, *v1alpha1.MonoRepositorySet:
+		default:
+			t.Errorf("unexpected object %T", obj)
+		}
+		assert.Equal(t, "shard=a", byObject.Label.String())
+	}
+}
+
+func TestLabelSelector(t *testing.T) {
+	c := config.Default()
+	selector, err := c.LabelSelector()
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+
+	c.Selector = "shard=a"
+	selector, err = c.LabelSelector()
+	assert.NoError(t, err)
+	assert.Equal(t, "shard=a", selector.String())
+
+	c.Selector = "shard in (a"
+	_, err = c.LabelSelector()
+	assert.ErrorContains(t, err, `invalid selector "shard in (a"`)
+}
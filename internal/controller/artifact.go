@@ -0,0 +1,354 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	rtime "github.com/vmware-labs/reconciler-runtime/time"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ReflectArtifact downloads the artifact of a ready source, filters its contents
+// using the include rules of the parent and, when the checksum of the filtered
+// files has changed, stores and publishes a new filtered artifact. The parent
+// and its components are filtered in a single pass over the artifact.
+func ReflectArtifact(ctx context.Context, o Options, parent *v1alpha1.MonoRepository, artifact *apiv1.Artifact) {
+	log := util.L(ctx)
+	s := o.Storage
+
+	if artifact == nil {
+		return
+	}
+
+	m := newSourceMetrics(parent)
+	snap, err := openSnapshot(ctx, o, artifact, m)
+	if err != nil {
+		warn(ctx, parent, v1alpha1.DownloadFailedReason, err)
+		return
+	}
+	defer snap.Close()
+	parent.Status.MarkDownloaded(ctx, artifact.Revision)
+
+	include, err := resolveInclude(snap, parent)
+	if err != nil {
+		var step *stepError
+		if errors.As(err, &step) {
+			warn(ctx, parent, step.reason, err)
+			return
+		}
+		parent.Status.MarkFilterFailed(ctx, v1alpha1.IncludeFileNotFoundReason, err)
+		return
+	}
+
+	start := time.Now()
+	total := 0
+	match := util.NewFilter(include, parent.Spec.Includes, parent.Spec.Exclude)
+	rules := util.NewRuleMatches(include, parent.Spec.Includes)
+	filtered := &filter{
+		dir: s.ArtifactPath("MonoRepository", parent.Namespace, parent.Name, ""),
+		match: func(name string) bool {
+			total++
+			rules.Observe(name)
+			return match(name)
+		},
+	}
+	components := componentFilters(o, parent)
+	filters := []*filter{filtered}
+	for _, f := range components {
+		filters = append(filters, f)
+	}
+	if err := snap.Filter(filters...); err != nil {
+		warn(ctx, parent, v1alpha1.HashFailedReason, err)
+		return
+	}
+	defer func() {
+		for _, f := range filters {
+			f.Close()
+		}
+	}()
+	manifest := filtered.manifest
+
+	filteredFiles := manifest.Files()
+	log.Info("Using files for checksum calculation", "files", filteredFiles)
+	parent.Status.ObservedFileList = previewFiles(filteredFiles)
+
+	parent.Status.UnmatchedIncludes = rules.Unmatched()
+	if len(parent.Status.UnmatchedIncludes) > 0 {
+		log.Info("Include rules did not match any files", "rules", parent.Status.UnmatchedIncludes)
+	}
+
+	if len(filteredFiles) == 0 {
+		err := fmt.Errorf("include rules did not match any of the %d files in the artifact", total)
+		switch parent.Spec.EmptyMatchPolicy {
+		case v1alpha1.EmptyMatchPolicyFail:
+			warn(ctx, parent, v1alpha1.NoFilesMatchedReason, err)
+			return
+		case v1alpha1.EmptyMatchPolicyAllow:
+		default:
+			reconcilers.RetrieveConfigOrDie(ctx).Recorder.Event(parent, corev1.EventTypeWarning, v1alpha1.NoFilesMatchedReason, err.Error())
+		}
+	}
+
+	hash, err := manifest.Hash1()
+	if err != nil {
+		warn(ctx, parent, v1alpha1.HashFailedReason, err)
+		return
+	}
+	m.observeHash(start)
+	m.observeFiles(total, len(filteredFiles))
+	parent.Status.MarkFiltered(ctx, len(filteredFiles), hash)
+
+	log.Info("Calculated checksum", "checksum", hash)
+
+	if parent.Status.Artifact != nil && parent.Status.Artifact.Checksum == hash && s.Exists(parent.Status.Artifact.Path) {
+		// nothing has changed, do nothing
+		log.Info("Source hasn't changed, there is nothing to update")
+		m.unchanged()
+
+		key := fmt.Sprintf("%s/%s@%s", parent.Namespace, parent.Name, artifact.Revision)
+		if o.UnchangedEvents.Allow(key, rtime.RetrieveNow(ctx)) {
+			reconcilers.RetrieveConfigOrDie(ctx).Recorder.Eventf(parent, corev1.EventTypeNormal, "ArtifactUnchanged",
+				"Filtered artifact unchanged at revision %s, checksum %s", artifact.Revision, hash)
+		}
+
+		fileManifest, err := ensureManifest(s, parent.Status.Artifact.Path, manifest, parent.Status.FileManifest)
+		if err != nil {
+			parent.Status.MarkPublishFailed(ctx, err)
+			return
+		}
+		parent.Status.FileManifest = fileManifest
+	} else {
+		old := "none"
+		if parent.Status.Artifact != nil {
+			old = parent.Status.Artifact.Checksum
+		}
+
+		log.Info("Source has changed! updating status with new checksum",
+			"checksum", hash,
+			"old", old)
+
+		previous, known := previousManifest(s, parent)
+
+		artifactPath := s.ArtifactPath("MonoRepository", parent.Namespace, parent.Name, artifactFileName(hash))
+		digest, size, err := filtered.Store(artifactPath)
+		if err != nil {
+			parent.Status.MarkPublishFailed(ctx, err)
+			return
+		}
+
+		fileManifest, err := storeManifest(s, artifactPath, manifest)
+		if err != nil {
+			parent.Status.MarkPublishFailed(ctx, err)
+			return
+		}
+
+		log.Info("Stored filtered artifact", "path", artifactPath, "digest", digest, "size", size)
+
+		if old != hash {
+			// the artifact may also be rewritten when the storage has been lost, only
+			// report changes when the content differs
+			parent.Status.ChangedFiles = nil
+			if known {
+				parent.Status.ChangedFiles = changedFiles(manifest, previous)
+			}
+			m.changed()
+			message := fmt.Sprintf("Filtered artifact changed from %s to %s at revision %s, %s", old, hash, artifact.Revision, summarise(parent.Status.ChangedFiles))
+			reconcilers.RetrieveConfigOrDie(ctx).Recorder.Event(parent, corev1.EventTypeNormal, "ArtifactChanged", message)
+		}
+
+		parent.Status.Artifact = &v1alpha1.Artifact{
+			Path:           artifactPath,
+			URL:            s.URL(artifactPath),
+			Revision:       artifact.Revision,
+			Checksum:       hash,
+			Digest:         digest,
+			LastUpdateTime: v1.NewTime(rtime.RetrieveNow(ctx)),
+			Size:           &size,
+			Metadata:       artifact.Metadata,
+		}
+		parent.Status.URL = parent.Status.Artifact.URL
+		parent.Status.FileManifest = fileManifest
+
+		if err := s.GarbageCollect(artifactPath, manifestPath(artifactPath)); err != nil {
+			log.Error(err, "unable to remove previous artifacts", "path", artifactPath)
+		}
+	}
+
+	if err := reflectComponents(ctx, o, parent, components, artifact); err != nil {
+		parent.Status.MarkPublishFailed(ctx, err)
+		return
+	}
+
+	parent.Status.ObservedInclude = include
+	parent.Status.MarkReady(ctx, hash)
+}
+
+// resolveInclude returns the include rules of the parent, appending the rules
+// read from the IncludeFrom file within the artifact. Each block of rules is
+// preceded by a comment naming where it came from.
+func resolveInclude(snap snapshot, parent *v1alpha1.MonoRepository) (string, error) {
+	if parent.Spec.IncludeFrom == "" {
+		return parent.Spec.Include, nil
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+parent.Spec.IncludeFrom), "/")
+	content, found, err := snap.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("include file %s not found in artifact", name)
+	}
+
+	var b strings.Builder
+	if parent.Spec.Include != "" {
+		b.WriteString("# spec.include\n")
+		b.WriteString(strings.TrimSuffix(parent.Spec.Include, "\n"))
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "# %s\n", name)
+	b.WriteString(strings.TrimSuffix(string(content), "\n"))
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// stepError records the step of the reconcile that failed, as the reason of the
+// Warning event, when it differs from the step being performed. A streamed
+// artifact is downloaded while it is hashed.
+type stepError struct {
+	reason string
+	err    error
+}
+
+func (e *stepError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stepError) Unwrap() error {
+	return e.err
+}
+
+// warn marks the condition of the step that failed as False, emitting a Warning
+// event with the reason.
+func warn(ctx context.Context, parent *v1alpha1.MonoRepository, reason string, err error) {
+	var step *stepError
+	if errors.As(err, &step) {
+		reason = step.reason
+	}
+	reconcilers.RetrieveConfigOrDie(ctx).Recorder.Event(parent, corev1.EventTypeWarning, reason, err.Error())
+
+	switch reason {
+	case v1alpha1.DownloadFailedReason, v1alpha1.ExtractFailedReason:
+		parent.Status.MarkDownloadFailed(ctx, reason, err)
+	default:
+		parent.Status.MarkFilterFailed(ctx, reason, err)
+	}
+}
+
+// reflectSource reflects the artifact of the source on the parent once the
+// source is ready, otherwise the parent reports what it is waiting for.
+func reflectSource(ctx context.Context, o Options, parent *v1alpha1.MonoRepository, kind string, key types.NamespacedName, src meta.ObjectWithConditions, artifact *apiv1.Artifact) {
+	name := fmt.Sprintf("%s %s", kind, key)
+	if !isReady(src) || artifact == nil {
+		parent.Status.MarkSourceNotReady(ctx, name, apimeta.FindStatusCondition(src.GetConditions(), meta.ReadyCondition))
+		return
+	}
+
+	parent.Status.MarkSourceReady(ctx, name, artifact.Revision)
+	ReflectArtifact(ctx, o, parent, artifact)
+}
+
+// reflectChildError reports a source that could not be created or updated on
+// the parent, returning false when there is no error. A source with the same
+// name that is not owned by the parent is a conflict, a source rejected by the
+// API server is invalid.
+func reflectChildError(ctx context.Context, parent *v1alpha1.MonoRepository, kind string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	name := fmt.Sprintf("%s %s/%s", kind, parent.Namespace, parent.Name)
+	switch {
+	case apierrs.IsAlreadyExists(err):
+		parent.Status.MarkSourceFailed(ctx, v1alpha1.SourceConflictReason, name, err)
+	case apierrs.IsInvalid(err):
+		parent.Status.MarkSourceFailed(ctx, v1alpha1.InvalidSourceReason, name, err)
+	default:
+		parent.Status.MarkSourceFailed(ctx, v1alpha1.MonoRepositoryFailedReason, name, err)
+	}
+	return true
+}
+
+// fetchArtifact downloads the artifact into a directory under tmp and extracts
+// it into dir, recording the time taken by each step in m.
+func fetchArtifact(ctx context.Context, artifact *apiv1.Artifact, dir, tmp string, m *sourceMetrics) error {
+	log := util.L(ctx)
+
+	tempDir, err := os.MkdirTemp(tmp, "tmp")
+	if err != nil {
+		return err
+	}
+	// cleanup on exit
+	defer os.RemoveAll(tempDir)
+
+	log.Info("downloading artifact", "url", artifact.URL, "revision", artifact.Revision)
+
+	start := time.Now()
+	tarGzLocation := filepath.Join(tempDir, "artifact.tar.gz")
+	if err := util.DownloadFile(tarGzLocation, artifact.URL); err != nil {
+		return err
+	}
+	fi, err := os.Stat(tarGzLocation)
+	if err != nil {
+		return err
+	}
+	m.observeDownload(start, fi.Size())
+
+	start = time.Now()
+	if err := util.ExtractTarGz(tarGzLocation, dir); err != nil {
+		return &stepError{reason: v1alpha1.ExtractFailedReason, err: err}
+	}
+	m.observeExtract(start)
+	return nil
+}
+
+// cacheKey identifies the content of an artifact, falling back to its URL for
+// sources that do not report a digest.
+func cacheKey(artifact *apiv1.Artifact) string {
+	if artifact.Digest != "" {
+		return artifact.Digest
+	}
+	return artifact.URL
+}
+
+// artifactFileName derives a file system safe name for the filtered artifact from
+// its dirhash checksum, e.g. h1:47DEQpj8...= becomes e3b0c442....tar.gz.
+func artifactFileName(checksum string) string {
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(checksum, "h1:"))
+	if err != nil {
+		return fmt.Sprintf("%x.tar.gz", sha256.Sum256([]byte(checksum)))
+	}
+	return fmt.Sprintf("%x.tar.gz", sum)
+}
+
+// isReady returns true when the source has a Ready condition with a status of True.
+func isReady(source meta.ObjectWithConditions) bool {
+	return apimeta.IsStatusConditionTrue(source.GetConditions(), meta.ReadyCondition)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func NewBucketReconciler(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.ChildReconciler[*v1alpha1.MonoRepository, *apiv1beta2.Bucket, *apiv1beta2.BucketList]{
+		Name: "Bucket",
+		DesiredChild: func(ctx context.Context, parent *v1alpha1.MonoRepository) (*apiv1beta2.Bucket, error) {
+			if parent.Spec.Bucket == nil {
+				return nil, nil
+			}
+
+			child := &apiv1beta2.Bucket{
+				ObjectMeta: v1.ObjectMeta{
+					Labels:      FilterLabelsOrAnnotations(reconcilers.MergeMaps(parent.Labels)),
+					Annotations: FilterLabelsOrAnnotations(reconcilers.MergeMaps(parent.Annotations)),
+					Name:        parent.Name,
+					Namespace:   parent.Namespace,
+				},
+				Spec: *parent.Spec.Bucket,
+			}
+
+			return child, nil
+		},
+		MergeBeforeUpdate: func(actual, desired *apiv1beta2.Bucket) {
+			actual.Labels = desired.Labels
+			actual.Spec = desired.Spec
+		},
+		ReflectChildStatusOnParent: func(ctx context.Context, parent *v1alpha1.MonoRepository, child *apiv1beta2.Bucket, err error) {
+			if reflectChildError(ctx, parent, "Bucket", err) || child == nil {
+				return
+			}
+			reflectSource(ctx, o, parent, "Bucket", client.ObjectKeyFromObject(child), child, child.Status.Artifact)
+		},
+		Sanitize: func(child *apiv1beta2.Bucket) any {
+			return child.Spec
+		},
+	}
+}
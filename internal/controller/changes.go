@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+)
+
+const (
+	// maxChangedFiles bounds the number of paths reported in the status.
+	maxChangedFiles = 100
+
+	// maxSummaryFiles bounds the number of paths included in an event message.
+	maxSummaryFiles = 10
+
+	// maxObservedFiles bounds the number of paths previewed in the status, the
+	// complete list is in the file manifest.
+	maxObservedFiles = 100
+)
+
+// manifestPath returns the path of the file manifest stored alongside an artifact.
+func manifestPath(artifactPath string) string {
+	return strings.TrimSuffix(artifactPath, ".tar.gz") + ".manifest"
+}
+
+// storeManifest writes the manifest alongside the artifact at artifactPath,
+// returning the reference to it that is held in the status.
+func storeManifest(s *storage.Storage, artifactPath string, manifest util.Manifest) (*v1alpha1.FileManifest, error) {
+	var buf bytes.Buffer
+	if _, err := manifest.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	path := manifestPath(artifactPath)
+	if err := s.WriteFile(path, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return &v1alpha1.FileManifest{
+		Count:  len(manifest),
+		Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(buf.Bytes())),
+		Path:   path,
+		URL:    s.URL(path),
+	}, nil
+}
+
+// ensureManifest returns the reference to the manifest of the artifact at
+// artifactPath, storing the manifest again when it is missing.
+func ensureManifest(s *storage.Storage, artifactPath string, manifest util.Manifest, current *v1alpha1.FileManifest) (*v1alpha1.FileManifest, error) {
+	if current != nil && current.Path == manifestPath(artifactPath) && s.Exists(current.Path) {
+		return current, nil
+	}
+	return storeManifest(s, artifactPath, manifest)
+}
+
+// previewFiles returns at most maxObservedFiles of the paths, one per line.
+func previewFiles(files []string) string {
+	if len(files) > maxObservedFiles {
+		files = files[:maxObservedFiles]
+	}
+	return strings.Join(files, "\n")
+}
+
+// previousManifest returns the manifest of the last accepted artifact. An empty
+// manifest is returned when there is no previous artifact and false when the
+// previous manifest is no longer available.
+func previousManifest(s *storage.Storage, parent *v1alpha1.MonoRepository) (util.Manifest, bool) {
+	if parent.Status.Artifact == nil {
+		return util.Manifest{}, true
+	}
+
+	data, err := s.ReadFile(manifestPath(parent.Status.Artifact.Path))
+	if err != nil {
+		return nil, false
+	}
+	manifest, err := util.ReadManifest(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	return manifest, true
+}
+
+// changedFiles lists the differences between the manifests, reporting at most
+// maxChangedFiles paths.
+func changedFiles(current, previous util.Manifest) *v1alpha1.ChangedFiles {
+	added, removed, modified := current.Diff(previous)
+
+	changes := &v1alpha1.ChangedFiles{
+		Count: len(added) + len(removed) + len(modified),
+	}
+
+	remaining := maxChangedFiles
+	take := func(files []string) []string {
+		if len(files) > remaining {
+			files = files[:remaining]
+			changes.Truncated = true
+		}
+		remaining -= len(files)
+		if len(files) == 0 {
+			return nil
+		}
+		return files
+	}
+	changes.Added = take(added)
+	changes.Removed = take(removed)
+	changes.Modified = take(modified)
+
+	return changes
+}
+
+// summarise returns a short, human readable, description of the changes.
+func summarise(changes *v1alpha1.ChangedFiles) string {
+	if changes == nil {
+		return "changed files are unknown, the previous file manifest is not available"
+	}
+
+	summary := fmt.Sprintf("%d files changed", changes.Count)
+
+	var paths []string
+	for _, group := range []struct {
+		prefix string
+		files  []string
+	}{
+		{"+", changes.Added},
+		{"-", changes.Removed},
+		{"~", changes.Modified},
+	} {
+		for _, file := range group.files {
+			paths = append(paths, group.prefix+file)
+		}
+	}
+	if len(paths) == 0 {
+		return summary
+	}
+	if len(paths) > maxSummaryFiles {
+		paths = paths[:maxSummaryFiles]
+	}
+	if len(paths) < changes.Count {
+		paths = append(paths, "...")
+	}
+	return fmt.Sprintf("%s: %s", summary, strings.Join(paths, ", "))
+}
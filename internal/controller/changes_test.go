@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewFiles(t *testing.T) {
+	assert.Equal(t, "", previewFiles(nil))
+	assert.Equal(t, "a.txt\nb.txt", previewFiles([]string{"a.txt", "b.txt"}))
+
+	var files []string
+	for i := 0; i < maxObservedFiles+10; i++ {
+		files = append(files, fmt.Sprintf("file-%03d.txt", i))
+	}
+	preview := strings.Split(previewFiles(files), "\n")
+	assert.Len(t, preview, maxObservedFiles)
+	assert.Equal(t, files[:maxObservedFiles], preview)
+}
+
+func TestEnsureManifest(t *testing.T) {
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	manifest := util.Manifest{
+		"dir01/test.txt": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		"dir02/test.txt": "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752",
+	}
+	artifactPath := "monorepository/dev/mono-repository/artifact.tar.gz"
+
+	stored, err := storeManifest(s, artifactPath, manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stored.Count)
+	assert.Equal(t, "monorepository/dev/mono-repository/artifact.manifest", stored.Path)
+	assert.Equal(t, "http://localhost:9090/monorepository/dev/mono-repository/artifact.manifest", stored.URL)
+
+	// the digest of the manifest matches the h1 checksum of the files
+	hash, err := manifest.Hash1()
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:"+strings.TrimSuffix(artifactFileName(hash), ".tar.gz"), stored.Digest)
+
+	data, err := s.ReadFile(stored.Path)
+	assert.NoError(t, err)
+	read, err := util.ReadManifest(strings.NewReader(string(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, manifest, read)
+
+	// an existing manifest is kept, a missing one is stored again
+	existing, err := ensureManifest(s, artifactPath, manifest, stored)
+	assert.NoError(t, err)
+	assert.Same(t, stored, existing)
+
+	assert.NoError(t, s.RemoveAll(stored.Path))
+	restored, err := ensureManifest(s, artifactPath, manifest, stored)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, restored)
+	assert.True(t, s.Exists(stored.Path))
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	rtime "github.com/vmware-labs/reconciler-runtime/time"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// componentFilters returns a filter for each component of the parent, keyed by
+// name, so that the components are filtered in the same pass as the parent.
+func componentFilters(o Options, parent *v1alpha1.MonoRepository) map[string]*filter {
+	filters := make(map[string]*filter, len(parent.Spec.Components))
+	for name, component := range parent.Spec.Components {
+		filters[name] = &filter{
+			dir:   o.Storage.ArtifactPath("MonoRepository", parent.Namespace, componentDir(parent, name), ""),
+			match: util.NewFilter(component.Include, component.Includes, component.Exclude),
+		}
+	}
+	return filters
+}
+
+// reflectComponents stores a new artifact for every component whose checksum
+// has changed, using the filters the snapshot has already been filtered with.
+// The artifacts of components that have been removed from the spec are deleted.
+func reflectComponents(ctx context.Context, o Options, parent *v1alpha1.MonoRepository, filters map[string]*filter, artifact *apiv1.Artifact) error {
+	log := util.L(ctx)
+	s := o.Storage
+
+	var components map[string]v1alpha1.ComponentStatus
+	if len(parent.Spec.Components) > 0 {
+		components = make(map[string]v1alpha1.ComponentStatus, len(parent.Spec.Components))
+	}
+
+	names := make([]string, 0, len(parent.Spec.Components))
+	for name := range parent.Spec.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		filtered := filters[name]
+		manifest := filtered.manifest
+
+		hash, err := manifest.Hash1()
+		if err != nil {
+			return fmt.Errorf("component %s: %w", name, err)
+		}
+
+		status := v1alpha1.ComponentStatus{
+			Artifact:         parent.Status.Components[name].Artifact,
+			ObservedFileList: previewFiles(manifest.Files()),
+			FileManifest:     parent.Status.Components[name].FileManifest,
+		}
+
+		if status.Artifact == nil || status.Artifact.Checksum != hash || !s.Exists(status.Artifact.Path) {
+			artifactPath := s.ArtifactPath("MonoRepository", parent.Namespace, componentDir(parent, name), artifactFileName(hash))
+			digest, size, err := filtered.Store(artifactPath)
+			if err != nil {
+				return fmt.Errorf("component %s: %w", name, err)
+			}
+
+			log.Info("Stored filtered component artifact", "component", name, "path", artifactPath, "digest", digest, "size", size)
+
+			status.Artifact = &v1alpha1.Artifact{
+				Path:           artifactPath,
+				URL:            s.URL(artifactPath),
+				Revision:       artifact.Revision,
+				Checksum:       hash,
+				Digest:         digest,
+				LastUpdateTime: v1.NewTime(rtime.RetrieveNow(ctx)),
+				Size:           &size,
+				Metadata:       artifact.Metadata,
+			}
+
+			if err := s.GarbageCollect(artifactPath, manifestPath(artifactPath)); err != nil {
+				log.Error(err, "unable to remove previous component artifacts", "path", artifactPath)
+			}
+		}
+
+		status.FileManifest, err = ensureManifest(s, status.Artifact.Path, manifest, status.FileManifest)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", name, err)
+		}
+
+		components[name] = status
+	}
+
+	for name := range parent.Status.Components {
+		if _, ok := components[name]; ok {
+			continue
+		}
+		dir := s.ArtifactPath("MonoRepository", parent.Namespace, componentDir(parent, name), "")
+		if err := s.RemoveAll(dir); err != nil {
+			log.Error(err, "unable to remove component artifacts", "path", dir)
+		}
+	}
+
+	parent.Status.Components = components
+	return nil
+}
+
+// componentDir returns the directory, relative to the artifacts of the parent,
+// the artifacts of a component are stored in.
+func componentDir(parent *v1alpha1.MonoRepository, name string) string {
+	return path.Join(parent.Name, "components", name)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// EventLimiter limits how often a repeated event is emitted for the same key.
+// A nil EventLimiter allows every event.
+type EventLimiter struct {
+	interval time.Duration
+
+	m    sync.Mutex
+	last map[string]time.Time
+}
+
+// NewEventLimiter returns an EventLimiter that allows an event for a key at most
+// once per interval.
+func NewEventLimiter(interval time.Duration) *EventLimiter {
+	return &EventLimiter{
+		interval: interval,
+		last:     map[string]time.Time{},
+	}
+}
+
+// Allow returns true when no event has been allowed for key within the interval
+// before now, recording now as the time of the last event.
+func (l *EventLimiter) Allow(key string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	for k, t := range l.last {
+		if now.Sub(t) >= l.interval {
+			delete(l.last, k)
+		}
+	}
+
+	if _, ok := l.last[key]; ok {
+		return false
+	}
+	l.last[key] = now
+	return true
+}
@@ -0,0 +1,23 @@
+package controller_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/internal/controller"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventLimiter(t *testing.T) {
+	now := time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC)
+	l := controller.NewEventLimiter(time.Hour)
+
+	assert.True(t, l.Allow("dev/mono-repository", now))
+	assert.False(t, l.Allow("dev/mono-repository", now.Add(59*time.Minute)))
+	assert.True(t, l.Allow("dev/other-repository", now.Add(59*time.Minute)))
+	assert.True(t, l.Allow("dev/mono-repository", now.Add(time.Hour)))
+
+	var none *controller.EventLimiter
+	assert.True(t, none.Allow("dev/mono-repository", now))
+	assert.True(t, none.Allow("dev/mono-repository", now))
+}
@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/garethjevans/monorepository-controller/internal/util"
@@ -76,3 +77,97 @@ func TestHash(t *testing.T) {
 
 	assert.Equal(t, hash01, hash02)
 }
+
+func TestManifestDiff(t *testing.T) {
+	previous := util.Manifest{
+		"modified.txt":  "aaaa",
+		"removed.txt":   "bbbb",
+		"unchanged.txt": "cccc",
+	}
+	current := util.Manifest{
+		"added.txt":     "dddd",
+		"modified.txt":  "eeee",
+		"unchanged.txt": "cccc",
+	}
+
+	var buf bytes.Buffer
+	_, err := current.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "dddd  added.txt\neeee  modified.txt\ncccc  unchanged.txt\n", buf.String())
+
+	read, err := util.ReadManifest(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, current, read)
+
+	added, removed, modified := current.Diff(previous)
+	assert.Equal(t, []string{"added.txt"}, added)
+	assert.Equal(t, []string{"removed.txt"}, removed)
+	assert.Equal(t, []string{"modified.txt"}, modified)
+}
+
+func TestFilterIncludeExclude(t *testing.T) {
+	files := []string{
+		"go.mod",
+		"services/foo/main.go",
+		"services/foo/main_test.go",
+		"services/foo/README.md",
+		"services/bar/main.go",
+		"libs/common/common.go",
+	}
+
+	var filtered []string
+	filter := util.NewFilter("", []string{"/services/foo", "/libs"}, []string{"*_test.go", "*.md"})
+	for _, file := range files {
+		if filter(file) {
+			filtered = append(filtered, file)
+		}
+	}
+	assert.Equal(t, []string{"services/foo/main.go", "libs/common/common.go"}, filtered)
+
+	// the include string is still honoured, excludes apply to it as well
+	filter = util.NewFilter("go.*\nservices/bar", nil, []string{"go.mod"})
+	assert.False(t, filter("go.mod"))
+	assert.True(t, filter("services/bar/main.go"))
+	assert.False(t, filter("services/foo/main.go"))
+}
+
+func TestMatchDirectories(t *testing.T) {
+	files := []string{
+		"go.mod",
+		"services/foo/Dockerfile",
+		"services/foo/main.go",
+		"services/bar/main.go",
+		"services/baz/cmd/Dockerfile",
+		"libs/common/common.go",
+	}
+
+	dirs, err := util.MatchDirectories(files, "services/*", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"services/bar", "services/baz", "services/foo"}, dirs)
+
+	dirs, err = util.MatchDirectories(files, "services/*", "Dockerfile")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"services/foo"}, dirs)
+
+	_, err = util.MatchDirectories(files, "services/[", "")
+	assert.Error(t, err)
+}
+
+func TestRuleMatches(t *testing.T) {
+	files := []string{
+		"go.mod",
+		"services/foo/main.go",
+		"libs/common/common.go",
+	}
+
+	rules := util.NewRuleMatches("# spec.include\n/go.mod\n/servces/foo\n!**/*_test.go\n", []string{"/libs", "/docs"})
+	for _, file := range files {
+		rules.Observe(file)
+	}
+	assert.Equal(t, []string{"/servces/foo", "/docs"}, rules.Unmatched())
+
+	// a rule matches regardless of the other rules, so negations are ignored
+	rules = util.NewRuleMatches("/services\n!/services/foo", nil)
+	rules.Observe("services/foo/main.go")
+	assert.Empty(t, rules.Unmatched())
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"io"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	downloadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monorepository_download_duration_seconds",
+		Help:    "Time taken to download the artifact of the source.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"namespace", "name"})
+
+	downloadBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monorepository_download_bytes",
+		Help:    "Size of the downloaded artifact of the source.",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 12),
+	}, []string{"namespace", "name"})
+
+	extractDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monorepository_extract_duration_seconds",
+		Help:    "Time taken to extract the artifact of the source into the cache.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"namespace", "name"})
+
+	hashDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monorepository_hash_duration_seconds",
+		Help:    "Time taken to filter and hash the files of the artifact, including the download when streaming.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"namespace", "name"})
+
+	files = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "monorepository_files",
+		Help:    "Number of files in the artifact of the source (total) and selected by the include rules (filtered).",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"namespace", "name", "type"})
+
+	checksumChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "monorepository_checksum_changes_total",
+		Help: "Number of reconciles that produced a new filtered artifact.",
+	}, []string{"namespace", "name"})
+
+	unchangedReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "monorepository_unchanged_reconciles_total",
+		Help: "Number of reconciles where the filtered files were unchanged and no artifact was produced.",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		downloadDuration,
+		downloadBytes,
+		extractDuration,
+		hashDuration,
+		files,
+		checksumChanges,
+		unchangedReconciles,
+	)
+}
+
+// sourceMetrics records the metrics of a single MonoRepository. A nil
+// sourceMetrics records nothing.
+type sourceMetrics struct {
+	namespace string
+	name      string
+}
+
+func newSourceMetrics(parent *v1alpha1.MonoRepository) *sourceMetrics {
+	return &sourceMetrics{namespace: parent.Namespace, name: parent.Name}
+}
+
+func (m *sourceMetrics) observeDownload(start time.Time, size int64) {
+	if m == nil {
+		return
+	}
+	downloadDuration.WithLabelValues(m.namespace, m.name).Observe(time.Since(start).Seconds())
+	downloadBytes.WithLabelValues(m.namespace, m.name).Observe(float64(size))
+}
+
+func (m *sourceMetrics) observeExtract(start time.Time) {
+	if m == nil {
+		return
+	}
+	extractDuration.WithLabelValues(m.namespace, m.name).Observe(time.Since(start).Seconds())
+}
+
+func (m *sourceMetrics) observeHash(start time.Time) {
+	if m == nil {
+		return
+	}
+	hashDuration.WithLabelValues(m.namespace, m.name).Observe(time.Since(start).Seconds())
+}
+
+func (m *sourceMetrics) observeFiles(total, filtered int) {
+	if m == nil {
+		return
+	}
+	files.WithLabelValues(m.namespace, m.name, "total").Observe(float64(total))
+	files.WithLabelValues(m.namespace, m.name, "filtered").Observe(float64(filtered))
+}
+
+func (m *sourceMetrics) changed() {
+	if m == nil {
+		return
+	}
+	checksumChanges.WithLabelValues(m.namespace, m.name).Inc()
+}
+
+func (m *sourceMetrics) unchanged() {
+	if m == nil {
+		return
+	}
+	unchangedReconciles.WithLabelValues(m.namespace, m.name).Inc()
+}
+
+// delete removes every series recorded for the MonoRepository, so that the
+// series of deleted MonoRepositories are no longer exported.
+func (m *sourceMetrics) delete() {
+	if m == nil {
+		return
+	}
+	labels := prometheus.Labels{"namespace": m.namespace, "name": m.name}
+	for _, vec := range []interface {
+		DeletePartialMatch(labels prometheus.Labels) int
+	}{
+		downloadDuration,
+		downloadBytes,
+		extractDuration,
+		hashDuration,
+		files,
+		checksumChanges,
+		unchangedReconciles,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// meteredReader counts the bytes read from a download, observing the download
+// when it is closed.
+type meteredReader struct {
+	io.ReadCloser
+	metrics *sourceMetrics
+	start   time.Time
+	size    int64
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *meteredReader) Close() error {
+	r.metrics.observeDownload(r.start, r.size)
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSourceMetrics(t *testing.T) {
+	m := newSourceMetrics(&v1alpha1.MonoRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "metrics", Name: "mono-repository"},
+	})
+
+	m.changed()
+	m.unchanged()
+	m.unchanged()
+	assert.Equal(t, 1.0, testutil.ToFloat64(checksumChanges.WithLabelValues("metrics", "mono-repository")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(unchangedReconciles.WithLabelValues("metrics", "mono-repository")))
+
+	m.observeFiles(10, 3)
+	assert.Equal(t, 1, testutil.CollectAndCount(files.WithLabelValues("metrics", "mono-repository", "total").(prometheus.Histogram)))
+	assert.Equal(t, 1, testutil.CollectAndCount(files.WithLabelValues("metrics", "mono-repository", "filtered").(prometheus.Histogram)))
+
+	r := &meteredReader{ReadCloser: io.NopCloser(strings.NewReader("0123456789")), metrics: m, start: time.Now()}
+	_, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, int64(10), r.size)
+	assert.Equal(t, 1, testutil.CollectAndCount(downloadBytes.WithLabelValues("metrics", "mono-repository").(prometheus.Histogram)))
+
+	// a nil sourceMetrics records nothing
+	var none *sourceMetrics
+	none.changed()
+	none.observeDownload(time.Now(), 10)
+	assert.Equal(t, 1.0, testutil.ToFloat64(checksumChanges.WithLabelValues("metrics", "mono-repository")))
+
+	// the series of a deleted MonoRepository are removed, other series are kept
+	other := newSourceMetrics(&v1alpha1.MonoRepository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "metrics", Name: "other-repository"},
+	})
+	other.changed()
+	m.delete()
+	deleted := prometheus.Labels{"namespace": "metrics", "name": "mono-repository"}
+	assert.Equal(t, 0, checksumChanges.DeletePartialMatch(deleted))
+	assert.Equal(t, 0, unchangedReconciles.DeletePartialMatch(deleted))
+	assert.Equal(t, 0, downloadBytes.DeletePartialMatch(deleted))
+	assert.Equal(t, 0, files.DeletePartialMatch(deleted))
+	assert.Equal(t, 1.0, testutil.ToFloat64(checksumChanges.WithLabelValues("metrics", "other-repository")))
+	none.delete()
+}
@@ -18,40 +18,146 @@ package controller
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"strings"
 
 	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/cache"
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
 	"github.com/garethjevans/monorepository-controller/internal/util"
 	"github.com/vmware-labs/reconciler-runtime/reconcilers"
-	"golang.org/x/mod/sumdb/dirhash"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 //+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositories,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositories/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositories/finalizers,verbs=update
 //+kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=buckets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=patch;create;update
 
-func NewMonoRepositoryReconciler(c reconcilers.Config) *reconcilers.ResourceReconciler[*v1alpha1.MonoRepository] {
+// Options holds the dependencies shared by the MonoRepository reconcilers.
+type Options struct {
+	// Storage holds the filtered artifacts.
+	Storage *storage.Storage
+
+	// Cache holds the extracted source artifacts, it may be nil to disable caching.
+	Cache *cache.Cache
+
+	// TempDir is the directory source artifacts are downloaded to before they
+	// are extracted, the system temporary directory is used when empty.
+	TempDir string
+
+	// WatchNamespaces are the namespaces the manager cache is limited to, every
+	// namespace is watched when empty.
+	WatchNamespaces []string
+
+	// AllowCrossNamespaceRefs allows a sourceRef to a source in another
+	// namespace, whose artifact is then republished by the referencing resource.
+	AllowCrossNamespaceRefs bool
+
+	// Selector is the label selector the manager cache is limited to, it may be
+	// nil to handle every resource.
+	Selector labels.Selector
+
+	// UnchangedEvents limits the ArtifactUnchanged events, it may be nil to emit
+	// an event on every reconcile.
+	UnchangedEvents *EventLimiter
+
+	// CloudEvents receives a CloudEvent whenever a filtered artifact changes, it
+	// may be nil to send none.
+	CloudEvents *notify.CloudEventsSink
+}
+
+// watches returns true when resources in the namespace are in the manager cache.
+func (o Options) watches(namespace string) bool {
+	if len(o.WatchNamespaces) == 0 {
+		return true
+	}
+	for _, ns := range o.WatchNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// MonoRepositoryFinalizer is held by every MonoRepository until the artifacts it
+// published have been removed from the storage.
+const MonoRepositoryFinalizer = "monorepositories.source.garethjevans.org/finalizer"
+
+func NewMonoRepositoryReconciler(c reconcilers.Config, o Options) *reconcilers.ResourceReconciler[*v1alpha1.MonoRepository] {
 	return &reconcilers.ResourceReconciler[*v1alpha1.MonoRepository]{
 		Name: "MonoRepository",
-		Reconciler: reconcilers.Sequence[*v1alpha1.MonoRepository]{
-			NewResourceValidator(c),
+		Reconciler: &reconcilers.WithFinalizer[*v1alpha1.MonoRepository]{
+			Finalizer: MonoRepositoryFinalizer,
+			Reconciler: reconcilers.Sequence[*v1alpha1.MonoRepository]{
+				NewStorageFinalizer(c, o),
+				NewChangeNotifier(c, o),
+				NewResourceValidator(c, o),
+			},
 		},
 		Config: c,
 	}
 }
 
-func NewResourceValidator(c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+// NewStorageFinalizer removes the artifacts, manifests and component artifacts
+// of a MonoRepository that is being deleted from the storage, along with its
+// metric series.
+func NewStorageFinalizer(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.SyncReconciler[*v1alpha1.MonoRepository]{
+		Name: "StorageFinalizer",
+		Sync: func(ctx context.Context, parent *v1alpha1.MonoRepository) error {
+			return nil
+		},
+		Finalize: func(ctx context.Context, parent *v1alpha1.MonoRepository) error {
+			dir := o.Storage.ArtifactPath("MonoRepository", parent.Namespace, parent.Name, "")
+			if err := o.Storage.RemoveAll(dir); err != nil {
+				return err
+			}
+			util.L(ctx).Info("Removed stored artifacts", "path", dir)
+			newSourceMetrics(parent).delete()
+			return nil
+		},
+	}
+}
+
+func NewResourceValidator(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return reconcilers.Sequence[*v1alpha1.MonoRepository]{
+		NewSpecValidator(c),
+		NewGitRepositoryReconciler(c, o),
+		NewOCIRepositoryReconciler(c, o),
+		NewBucketReconciler(c, o),
+		NewSourceRefReconciler(c, o),
+	}
+}
+
+// NewSpecValidator halts the reconciliation of a MonoRepository that does not
+// specify exactly one source.
+func NewSpecValidator(c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.SyncReconciler[*v1alpha1.MonoRepository]{
+		Name: "SpecValidator",
+		Sync: func(ctx context.Context, parent *v1alpha1.MonoRepository) error {
+			if err := parent.Spec.Validate(); err != nil {
+				parent.Status.MarkFailed(ctx, err)
+				return reconcilers.ErrHaltSubReconcilers
+			}
+			return nil
+		},
+	}
+}
+
+func NewGitRepositoryReconciler(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
 	return &reconcilers.ChildReconciler[*v1alpha1.MonoRepository, *apiv1beta2.GitRepository, *apiv1beta2.GitRepositoryList]{
 		Name: "GitRepository",
 		DesiredChild: func(ctx context.Context, parent *v1alpha1.MonoRepository) (*apiv1beta2.GitRepository, error) {
+			if parent.Spec.GitRepository == nil {
+				return nil, nil
+			}
+
 			child := &apiv1beta2.GitRepository{
 				ObjectMeta: v1.ObjectMeta{
 					Labels:      FilterLabelsOrAnnotations(reconcilers.MergeMaps(parent.Labels)),
@@ -59,7 +165,7 @@ func NewResourceValidator(c reconcilers.Config) reconcilers.SubReconciler[*v1alp
 					Name:        parent.Name,
 					Namespace:   parent.Namespace,
 				},
-				Spec: parent.Spec.GitRepository,
+				Spec: *parent.Spec.GitRepository,
 			}
 
 			return child, nil
@@ -69,100 +175,13 @@ func NewResourceValidator(c reconcilers.Config) reconcilers.SubReconciler[*v1alp
 			actual.Spec = desired.Spec
 		},
 		ReflectChildStatusOnParent: func(ctx context.Context, parent *v1alpha1.MonoRepository, child *apiv1beta2.GitRepository, err error) {
-			log := util.L(ctx)
-
-			if child != nil && isReady(child) {
-				tempDir, err := os.MkdirTemp("", "tmp")
-				if err != nil {
-					parent.Status.MarkFailed(ctx, err)
-					return
-				}
-				// cleanup on exit
-				defer os.RemoveAll(tempDir)
-
-				log.Info("created temp dir", "dir", tempDir)
-
-				// download the filter and copy from/to path
-				tarGzLocation := filepath.Join(tempDir, fmt.Sprintf("%s.tar.gz", child.Name))
-				err = util.DownloadFile(tarGzLocation, child.Status.Artifact.URL)
-				if err != nil {
-					parent.Status.MarkFailed(ctx, err)
-					return
-				}
-
-				// extract tar.gz to temp location
-				tarGzExtractedLocation := filepath.Join(tempDir, fmt.Sprintf("%s-extracted", child.Name))
-				err = util.ExtractTarGz(tarGzLocation, tarGzExtractedLocation)
-				if err != nil {
-					parent.Status.MarkFailed(ctx, err)
-					return
-				}
-
-				files, err := util.ListFiles(tarGzExtractedLocation)
-				if err != nil {
-					parent.Status.MarkFailed(ctx, err)
-					return
-				}
-
-				log.Info("Full file list", "files", files)
-				filteredFiles := util.FilterFileList(files, parent.Spec.Include)
-				log.Info("Using files for checksum calculation", "files", filteredFiles)
-				parent.Status.ObservedFileList = strings.Join(filteredFiles, "\n")
-
-				hash, err := dirhash.Hash1(filteredFiles, func(name string) (io.ReadCloser, error) {
-					return os.Open(filepath.Join(tarGzExtractedLocation, name))
-				})
-				if err != nil {
-					parent.Status.MarkFailed(ctx, err)
-					return
-				}
-
-				log.Info("Calculated checksum", "checksum", hash)
-
-				if parent.Status.Artifact != nil && parent.Status.Artifact.Checksum == hash {
-					// nothing has changed, do nothing
-					log.Info("Source hasn't changed, there is nothing to update")
-				} else {
-					old := "<NA>"
-					if parent.Status.Artifact != nil {
-						old = parent.Status.Artifact.Checksum
-					}
-
-					log.Info("Source has changed! updating status with new checksum",
-						"checksum", hash,
-						"old", old)
-					parent.Status.Artifact = &v1alpha1.Artifact{
-						Path:           child.Status.Artifact.Path,
-						URL:            child.Status.Artifact.URL,
-						Revision:       child.Status.Artifact.Revision,
-						Checksum:       hash,
-						Digest:         child.Status.Artifact.Digest,
-						LastUpdateTime: child.Status.Artifact.LastUpdateTime,
-						Size:           child.Status.Artifact.Size,
-						Metadata:       child.Status.Artifact.Metadata,
-					}
-					parent.Status.URL = child.Status.Artifact.URL
-				}
-
-				//resource.Status.ObservedInclude = resource.Spec.Include
-				parent.Status.MarkReady(ctx, hash)
+			if reflectChildError(ctx, parent, "GitRepository", err) || child == nil {
+				return
 			}
+			reflectSource(ctx, o, parent, "GitRepository", client.ObjectKeyFromObject(child), child, child.Status.Artifact)
 		},
 		Sanitize: func(child *apiv1beta2.GitRepository) any {
 			return child.Spec
 		},
 	}
 }
-
-func isReady(child *apiv1beta2.GitRepository) bool {
-	if child == nil {
-		return false
-	}
-
-	for _, c := range child.Status.Conditions {
-		if c.Type == "Ready" {
-			return c.Status == v1.ConditionTrue
-		}
-	}
-	return false
-}
@@ -1,22 +1,40 @@
 package controller_test
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/cache"
 	"github.com/garethjevans/monorepository-controller/internal/controller"
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
 	"github.com/garethjevans/monorepository-controller/internal/tests/resources"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/fluxcd/pkg/apis/meta"
 	apiv1 "github.com/fluxcd/source-controller/api/v1"
 	apiv1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
 	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	v1 "dies.dev/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/vmware-labs/reconciler-runtime/reconcilers"
 	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
 
@@ -41,26 +59,113 @@ func TestMonoRepository(t *testing.T) {
 			d.Namespace("dev")
 		})
 
-	go ServeDir(t, "testdata")
+	changedMonoRepo := baseMonoRepo.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("changed-repository")
+		})
+
+	includeFromMonoRepo := baseMonoRepo.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("include-from-repository")
+		})
+
+	componentsMonoRepo := baseMonoRepo.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("components-repository")
+		})
+
+	// fileManifest is the reference to the manifest stored alongside the artifact
+	// at path, the digest of the manifest is also the name of the artifact.
+	fileManifest := func(path string, count int) *v1alpha1.FileManifest {
+		manifest := strings.TrimSuffix(path, ".tar.gz") + ".manifest"
+		return &v1alpha1.FileManifest{
+			Count:  count,
+			Digest: "sha256:" + strings.TrimSuffix(filepath.Base(path), ".tar.gz"),
+			Path:   manifest,
+			URL:    "http://localhost:9090/" + manifest,
+		}
+	}
+
+	// readyConditions are the conditions of a MonoRepository that has published
+	// the filtered artifact of source.
+	readyConditions := func(source, revision string, count int, checksum string) []*v1.ConditionDie {
+		return []*v1.ConditionDie{
+			resources.MonoRepositoryConditionArtifactDownloadedBlank.True().Reason("Succeeded").Messagef("Downloaded artifact for revision %s", revision),
+			resources.MonoRepositoryConditionArtifactFilteredBlank.True().Reason("Succeeded").Messagef("Selected %d files with checksum %s", count, checksum),
+			resources.MonoRepositoryConditionArtifactPublishedBlank.True().Reason("Succeeded").Messagef("Repository has been successfully filtered with checksum %s", checksum),
+			resources.MonoRepositoryConditionBlank.True().Reason("Succeeded"),
+			resources.MonoRepositoryConditionSourceReadyBlank.True().Reason("Succeeded").Messagef("%s is ready with revision %s", source, revision),
+		}
+	}
+
+	ServeDir(t, "testdata")
+
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	artifactCache, err := cache.New(t.TempDir(), "", 1024*1024)
+	assert.NoError(t, err)
+
+	artifactPath := "monorepository/dev/mono-repository/e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.tar.gz"
+	changedArtifactPath := "monorepository/dev/changed-repository/d9026ba1e59263d47c353fd7da3cda2b04de14945cf9d643172e19fb2cbc0fac.tar.gz"
+	includeFromArtifactPath := "monorepository/dev/include-from-repository/03f0ee067cfc6ac93ca07b1df8cbd0de3359245b6f7be28c5a4b63c6d43a30c7.tar.gz"
+	componentsArtifactPath := "monorepository/dev/components-repository/e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.tar.gz"
+	oneArtifactPath := "monorepository/dev/components-repository/components/one/3960c6299b88c13995815300648c8ded6372582aee232c9a5836bb3e8acf68d8.tar.gz"
+	twoArtifactPath := "monorepository/dev/components-repository/components/two/356fb99533e43c1005e669aff8546116dc8a2f52b5d8a62d9bf946d6ce1e10bf.tar.gz"
+	now := time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC)
+
+	referencedGitRepository := &apiv1beta2.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mono",
+			Namespace: "flux-system",
+		},
+		Spec: apiv1beta2.GitRepositorySpec{
+			URL: "https://github.com/org/repo",
+		},
+		Status: apiv1beta2.GitRepositoryStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:    "Ready",
+					Status:  "True",
+					Reason:  "Succeeded",
+					Message: "stored artifact for revision 'main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7'",
+				},
+			},
+			Artifact: &apiv1.Artifact{
+				Path:     "gitrepository/flux-system/mono/68d842cd330410cf0672f862d9a799af4dcdc1d7.tar.gz",
+				URL:      "http://localhost:8080/file.tar.gz",
+				Revision: "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+				Digest:   "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
+			},
+		},
+	}
+
+	notifications := notificationServer(t)
+
+	missingGitRepository := referencedGitRepository.DeepCopy()
+	missingGitRepository.Name = "missing"
+	missingGitRepository.Status.Artifact.URL = "http://localhost:8080/missing.tar.gz"
+	missingGitRepository.Status.Artifact.Digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
 
 	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepository]{
 		"Contains a sub resource": {
 			Resource: baseMonoRepo.
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					})
 				}).DieReleasePtr(),
 
 			ExpectResource: baseMonoRepo.
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					})
 				}).
 				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
 					d.ConditionsDie(
-					//resources.ManagedResourceHealthyBlank.Reason("ReadyCondition").Unknown().Message("condition with type [Ready] not found on resource status"),
+						resources.MonoRepositoryConditionBlank.Unknown().Reason("SourceNotReady").Message("waiting for GitRepository dev/mono-repository to become ready"),
+						resources.MonoRepositoryConditionSourceReadyBlank.Unknown().Reason("SourceNotReady").Message("waiting for GitRepository dev/mono-repository to become ready"),
 					)
 				}).DieReleasePtr(),
 
@@ -92,14 +197,135 @@ func TestMonoRepository(t *testing.T) {
 			},
 		},
 
+		"Will report a conflict with a source it does not own": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository dev/mono-repository", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 1, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+					},
+					Spec: apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/other",
+					},
+				},
+			},
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionArtifactDownloadedBlank.True().Reason("Succeeded").Message("Downloaded artifact for revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+						resources.MonoRepositoryConditionArtifactFilteredBlank.True().Reason("Succeeded").Message("Selected 1 files with checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="),
+						resources.MonoRepositoryConditionArtifactPublishedBlank.True().Reason("Succeeded").Message("Repository has been successfully filtered with checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="),
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceConflict").Message(`unable to reconcile GitRepository dev/mono-repository: gitrepositories.source.toolkit.fluxcd.io "mono-repository" already exists`),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceConflict").Message(`unable to reconcile GitRepository dev/mono-repository: gitrepositories.source.toolkit.fluxcd.io "mono-repository" already exists`),
+					)
+				}).DieReleasePtr(),
+
+			ExpectCreates: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "mono-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					},
+				},
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo, scheme, corev1.EventTypeWarning, "CreationFailed", `Failed to create GitRepository %q: gitrepositories.source.toolkit.fluxcd.io "mono-repository" already exists`, "mono-repository"),
+			},
+		},
+
+		"Will report a source rejected by the API server": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "github.com/org/repo",
+					})
+				}).DieReleasePtr(),
+
+			WithReactors: []rtesting.ReactionFunc{
+				rtesting.InduceFailure("create", "GitRepository", rtesting.InduceFailureOpts{
+					Error: apierrs.NewInvalid(schema.GroupKind{Group: "source.toolkit.fluxcd.io", Kind: "GitRepository"}, "mono-repository", field.ErrorList{
+						field.Invalid(field.NewPath("spec", "url"), "github.com/org/repo", "should match '^(http|https|ssh)://.*$'"),
+					}),
+				}),
+			},
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "github.com/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("InvalidSource").Message(`unable to reconcile GitRepository dev/mono-repository: GitRepository.source.toolkit.fluxcd.io "mono-repository" is invalid: spec.url: Invalid value: "github.com/org/repo": should match '^(http|https|ssh)://.*$'`),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("InvalidSource").Message(`unable to reconcile GitRepository dev/mono-repository: GitRepository.source.toolkit.fluxcd.io "mono-repository" is invalid: spec.url: Invalid value: "github.com/org/repo": should match '^(http|https|ssh)://.*$'`),
+					)
+				}).DieReleasePtr(),
+
+			ExpectCreates: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "mono-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.GitRepositorySpec{
+						URL: "github.com/org/repo",
+					},
+				},
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo, scheme, corev1.EventTypeWarning, "CreationFailed", `Failed to create GitRepository %q: GitRepository.source.toolkit.fluxcd.io "mono-repository" is invalid: spec.url: Invalid value: "github.com/org/repo": should match '^(http|https|ssh)://.*$'`, "mono-repository"),
+			},
+		},
+
 		"Will reconcile a passing gitrepository": {
+			Now: now,
 			Resource: baseMonoRepo.
 				MetadataDie(func(d *v1.ObjectMetaDie) {
 					d.CreationTimestamp(metav1.Time{})
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					})
 				}).
@@ -113,22 +339,24 @@ func TestMonoRepository(t *testing.T) {
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					}).DieReleasePtr()
 				}).
 				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
-					d.ConditionsDie(resources.MonoRepositoryConditionBlank.Status("True").Reason("Succeeded").Message("Repository has been successfully filtered with checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")).DieReleasePtr()
+					d.ConditionsDie(readyConditions("GitRepository dev/mono-repository", "main@sha1:531d5230bf97e76e168d1817de64a161195f433d", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
 					d.Artifact(&v1alpha1.Artifact{
-						Path:           "gitrepository/dev/my-mono-repository/531d5230bf97e76e168d1817de64a161195f433d.tar.gz",
-						URL:            "http://localhost:8080/file.tar.gz",
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
 						Revision:       "main@sha1:531d5230bf97e76e168d1817de64a161195f433d",
 						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
-						Digest:         "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
-						LastUpdateTime: metav1.Time{},
-						Size:           ptr.To(int64(12742)),
-					}).DieReleasePtr()
-					d.URL("http://localhost:8080/file.tar.gz")
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
 				}).DieReleasePtr(),
 
 			GivenObjects: []client.Object{
@@ -171,30 +399,35 @@ func TestMonoRepository(t *testing.T) {
 					},
 				},
 			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision main@sha1:531d5230bf97e76e168d1817de64a161195f433d, 0 files changed"),
+			},
 		},
 
 		"Will reconcile a when there is nothing to update": {
+			Now: now,
 			Resource: baseMonoRepo.
 				MetadataDie(func(d *v1.ObjectMetaDie) {
 					d.CreationTimestamp(metav1.Time{})
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					})
 				}).
 				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
 					d.Artifact(&v1alpha1.Artifact{
-						Path:           "gitrepository/dev/my-mono-repository/531d5230bf97e76e168d1817de64a161195f433d.tar.gz",
-						URL:            "http://localhost:8080/file.tar.gz",
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
 						Revision:       "main@sha1:531d5230bf97e76e168d1817de64a161195f433d",
 						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
-						Digest:         "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
-						LastUpdateTime: metav1.Time{},
-						Size:           ptr.To(int64(12742)),
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
 					}).DieReleasePtr()
-					d.URL("http://localhost:8080/file.tar.gz")
+					d.URL("http://localhost:9090/" + artifactPath)
 				}).DieReleasePtr(),
 
 			ExpectResource: baseMonoRepo.
@@ -203,22 +436,23 @@ func TestMonoRepository(t *testing.T) {
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					}).DieReleasePtr()
 				}).
 				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
-					d.ConditionsDie(resources.MonoRepositoryConditionBlank.Status("True").Reason("Succeeded").Message("Repository has been successfully filtered with checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")).DieReleasePtr()
+					d.ConditionsDie(readyConditions("GitRepository dev/mono-repository", "main@sha1:531d5230bf97e76e168d1817de64a161195f433d", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
 					d.Artifact(&v1alpha1.Artifact{
-						Path:           "gitrepository/dev/my-mono-repository/531d5230bf97e76e168d1817de64a161195f433d.tar.gz",
-						URL:            "http://localhost:8080/file.tar.gz",
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
 						Revision:       "main@sha1:531d5230bf97e76e168d1817de64a161195f433d",
 						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
-						Digest:         "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
-						LastUpdateTime: metav1.Time{},
-						Size:           ptr.To(int64(12742)),
-					}).DieReleasePtr()
-					d.URL("http://localhost:8080/file.tar.gz")
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
 				}).DieReleasePtr(),
 
 			GivenObjects: []client.Object{
@@ -261,16 +495,21 @@ func TestMonoRepository(t *testing.T) {
 					},
 				},
 			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactUnchanged", "Filtered artifact unchanged at revision main@sha1:531d5230bf97e76e168d1817de64a161195f433d, checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="),
+			},
 		},
 
 		"Will reconcile a when there are changes to apply": {
+			Now: now,
 			Resource: baseMonoRepo.
 				MetadataDie(func(d *v1.ObjectMetaDie) {
 					d.CreationTimestamp(metav1.Time{})
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					})
 				}).
@@ -293,22 +532,23 @@ func TestMonoRepository(t *testing.T) {
 					d.Generation(1)
 				}).
 				SpecDie(func(d *resources.MonoRepositorySpecDie) {
-					d.GitRepository(apiv1beta2.GitRepositorySpec{
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
 						URL: "https://github.com/org/repo",
 					}).DieReleasePtr()
 				}).
 				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
-					d.ConditionsDie(resources.MonoRepositoryConditionBlank.Status("True").Reason("Succeeded").Message("Repository has been successfully filtered with checksum h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")).DieReleasePtr()
+					d.ConditionsDie(readyConditions("GitRepository dev/mono-repository", "main@sha1:531d5230bf97e76e168d1817de64a161195f433d", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
 					d.Artifact(&v1alpha1.Artifact{
-						Path:           "gitrepository/dev/my-mono-repository/531d5230bf97e76e168d1817de64a161195f433d.tar.gz",
-						URL:            "http://localhost:8080/file.tar.gz",
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
 						Revision:       "main@sha1:531d5230bf97e76e168d1817de64a161195f433d",
 						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
-						Digest:         "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
-						LastUpdateTime: metav1.Time{},
-						Size:           ptr.To(int64(12742)),
-					}).DieReleasePtr()
-					d.URL("http://localhost:8080/file.tar.gz")
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
 				}).DieReleasePtr(),
 
 			GivenObjects: []client.Object{
@@ -351,10 +591,1142 @@ func TestMonoRepository(t *testing.T) {
 					},
 				},
 			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from h1:previous to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision main@sha1:531d5230bf97e76e168d1817de64a161195f433d, changed files are unknown, the previous file manifest is not available"),
+			},
 		},
-	}
 
-	ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
-		return controller.NewResourceValidator(c)
-	})
+		"Contains an oci sub resource": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.Unknown().Reason("SourceNotReady").Message("waiting for OCIRepository dev/mono-repository to become ready"),
+						resources.MonoRepositoryConditionSourceReadyBlank.Unknown().Reason("SourceNotReady").Message("waiting for OCIRepository dev/mono-repository to become ready"),
+					)
+				}).DieReleasePtr(),
+
+			ExpectCreates: []client.Object{
+				&apiv1beta2.OCIRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "mono-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					},
+				},
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo, scheme, corev1.EventTypeNormal, "Created", "Created OCIRepository %q", "mono-repository"),
+			},
+		},
+
+		"Will reconcile a passing ocirepository": {
+			Now: now,
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("OCIRepository dev/mono-repository", "latest@sha256:6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
+						Revision:       "latest@sha256:6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b",
+						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.OCIRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "mono-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					},
+					Status: apiv1beta2.OCIRepositoryStatus{
+						Conditions: []metav1.Condition{
+							{
+								Type:    "Ready",
+								Status:  "True",
+								Reason:  "Succeeded",
+								Message: "stored artifact for digest 'latest@sha256:6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b'",
+							},
+						},
+						Artifact: &apiv1.Artifact{
+							Path:     "ocirepository/dev/mono-repository/6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b.tar.gz",
+							URL:      "http://localhost:8080/file.tar.gz",
+							Revision: "latest@sha256:6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b",
+							Digest:   "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
+						},
+					},
+				},
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision latest@sha256:6e2dd8ef9a7ec5c5d1ab5ad0b4b9f4aa5d1a1d2e7a2c3f5e6b7a8c9d0e1f2a3b, 0 files changed"),
+			},
+		},
+
+		"Will reconcile a passing bucket": {
+			Now: now,
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Bucket(&apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio.minio.svc.cluster.local:9000",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Bucket(&apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio.minio.svc.cluster.local:9000",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("Bucket dev/mono-repository", "sha256:3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
+						Revision:       "sha256:3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4",
+						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "mono-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio.minio.svc.cluster.local:9000",
+					},
+					Status: apiv1beta2.BucketStatus{
+						Conditions: []metav1.Condition{
+							{
+								Type:    "Ready",
+								Status:  "True",
+								Reason:  "Succeeded",
+								Message: "stored artifact: revision 'sha256:3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4'",
+							},
+						},
+						Artifact: &apiv1.Artifact{
+							Path:     "bucket/dev/mono-repository/3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4.tar.gz",
+							URL:      "http://localhost:8080/file.tar.gz",
+							Revision: "sha256:3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4",
+							Digest:   "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
+						},
+					},
+				},
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision sha256:3b0a2e4d9e21e12b5c0e1a1b4e8d2c4fa7f9d1b0c6a5e3f2d1c0b9a8f7e6d5c4, 0 files changed"),
+			},
+		},
+
+		"Will reconcile a referenced gitrepository": {
+			Now: now,
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository flux-system/mono", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
+						Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7, 0 files changed"),
+			},
+		},
+
+		"Will not notify before the changed artifact is recorded": {
+			Now: now,
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Notify(&v1alpha1.Notify{
+						FluxEventsAddress: notifications.URL + "/flux",
+						Webhooks: []v1alpha1.Webhook{
+							{URL: notifications.URL + "/signed", SecretRef: &meta.LocalObjectReference{Name: "webhook-token"}},
+							{URL: notifications.URL + "/broken"},
+						},
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Notify(&v1alpha1.Notify{
+						FluxEventsAddress: notifications.URL + "/flux",
+						Webhooks: []v1alpha1.Webhook{
+							{URL: notifications.URL + "/signed", SecretRef: &meta.LocalObjectReference{Name: "webhook-token"}},
+							{URL: notifications.URL + "/broken"},
+						},
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository flux-system/mono", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           artifactPath,
+						URL:            "http://localhost:9090/" + artifactPath,
+						Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(artifactPath, 0))
+					d.URL("http://localhost:9090/" + artifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7, 0 files changed"),
+			},
+		},
+
+		"Will store an artifact for each component": {
+			Now: now,
+			Resource: componentsMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Components(map[string]v1alpha1.Component{
+						"one": {Include: "/dir01"},
+						"two": {Includes: []string{"/dir02"}, Exclude: []string{".*"}},
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: componentsMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Components(map[string]v1alpha1.Component{
+						"one": {Include: "/dir01"},
+						"two": {Includes: []string{"/dir02"}, Exclude: []string{".*"}},
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository flux-system/mono", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 0, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           componentsArtifactPath,
+						URL:            "http://localhost:9090/" + componentsArtifactPath,
+						Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+						Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+						Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(29)),
+					})
+					d.FileManifest(fileManifest(componentsArtifactPath, 0))
+					d.URL("http://localhost:9090/" + componentsArtifactPath)
+					d.ChangedFiles(&v1alpha1.ChangedFiles{})
+					d.Components(map[string]v1alpha1.ComponentStatus{
+						"one": {
+							Artifact: &v1alpha1.Artifact{
+								Path:           oneArtifactPath,
+								URL:            "http://localhost:9090/" + oneArtifactPath,
+								Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+								Checksum:       "h1:OWDGKZuIwTmVgVMAZIyN7WNyWCruIyyaWDa7PorPaNg=",
+								Digest:         "sha256:aab4fdb7c9e376230c747f2c79df75a6413c9cddfc463914ffaf9385f2419d85",
+								LastUpdateTime: metav1.NewTime(now),
+								Size:           ptr.To(int64(113)),
+							},
+							ObservedFileList: "dir01/test.txt",
+							FileManifest:     fileManifest(oneArtifactPath, 1),
+						},
+						"two": {
+							Artifact: &v1alpha1.Artifact{
+								Path:           twoArtifactPath,
+								URL:            "http://localhost:9090/" + twoArtifactPath,
+								Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+								Checksum:       "h1:NW+5lTPkPBAF5mmv+FRhFtyKL1K12KYtm/lG1s4eEL8=",
+								Digest:         "sha256:b6714411f37a4ed397e6c84836af3773bca362fdccfce47fdedc959458e5a525",
+								LastUpdateTime: metav1.NewTime(now),
+								Size:           ptr.To(int64(113)),
+							},
+							ObservedFileList: "dir02/test.txt",
+							FileManifest:     fileManifest(twoArtifactPath, 1),
+						},
+					})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, componentsMonoRepo.DieReleasePtr(), scheme),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(componentsMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+				rtesting.NewEvent(componentsMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU= at revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7, 0 files changed"),
+			},
+		},
+
+		"Will fail when the referenced source does not exist": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind: "Bucket",
+						Name: "missing",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind: "Bucket",
+						Name: "missing",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceNotFound").Message("Bucket dev/missing not found"),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceNotFound").Message("Bucket dev/missing not found"),
+					)
+				}).DieReleasePtr(),
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(&apiv1beta2.Bucket{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "missing",
+						Namespace: "dev",
+					},
+				}, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+		},
+
+		"Will report the files that have changed": {
+			Now: now,
+			Resource: changedMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+					d.Include("*.txt")
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.Artifact(&v1alpha1.Artifact{
+						Path:     "monorepository/dev/changed-repository/previous.tar.gz",
+						URL:      "http://localhost:9090/monorepository/dev/changed-repository/previous.tar.gz",
+						Revision: "main@sha1:531d5230bf97e76e168d1817de64a161195f433d",
+						Checksum: "h1:previous",
+					}).DieReleasePtr()
+				}).DieReleasePtr(),
+
+			Prepare: func(t *testing.T, ctx context.Context, tc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository]) (context.Context, error) {
+				// dir01/test.txt held different content and removed.txt has since been deleted
+				return ctx, s.WriteFile("monorepository/dev/changed-repository/previous.manifest", []byte(
+					"0000000000000000000000000000000000000000000000000000000000000000  dir01/test.txt\n"+
+						"1111111111111111111111111111111111111111111111111111111111111111  removed.txt\n"))
+			},
+
+			ExpectResource: changedMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+					d.Include("*.txt")
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository dev/changed-repository", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 2, "h1:2QJroeWSY9R8NT/X2jzaKwTeFJRc+dZDFy4Z+yy8D6w=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           changedArtifactPath,
+						URL:            "http://localhost:9090/" + changedArtifactPath,
+						Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+						Checksum:       "h1:2QJroeWSY9R8NT/X2jzaKwTeFJRc+dZDFy4Z+yy8D6w=",
+						Digest:         "sha256:f8ce6d99be0c1a497c24f0735e8fc10119a5cfd8aad398bb64de18e6a3d262ad",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(132)),
+					})
+					d.FileManifest(fileManifest(changedArtifactPath, 2))
+					d.URL("http://localhost:9090/" + changedArtifactPath)
+					d.ObservedFileList("dir01/test.txt\ndir02/test.txt")
+					d.ObservedInclude("*.txt")
+					d.ChangedFiles(&v1alpha1.ChangedFiles{
+						Count:    3,
+						Added:    []string{"dir02/test.txt"},
+						Removed:  []string{"removed.txt"},
+						Modified: []string{"dir01/test.txt"},
+					})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "changed-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "source.garethjevans.org/v1alpha1",
+								Kind:               "MonoRepository",
+								Name:               "changed-repository",
+								Controller:         ptr.To(true),
+								BlockOwnerDeletion: ptr.To(true),
+							},
+						},
+					},
+					Spec: apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					},
+					Status: apiv1beta2.GitRepositoryStatus{
+						Conditions: []metav1.Condition{
+							{
+								Type:    "Ready",
+								Status:  "True",
+								Reason:  "Succeeded",
+								Message: "stored artifact for revision 'main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7'",
+							},
+						},
+						Artifact: &apiv1.Artifact{
+							Path:     "gitrepository/dev/changed-repository/68d842cd330410cf0672f862d9a799af4dcdc1d7.tar.gz",
+							URL:      "http://localhost:8080/file.tar.gz",
+							Revision: "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+							Digest:   "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
+						},
+					},
+				},
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(changedMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from h1:previous to h1:2QJroeWSY9R8NT/X2jzaKwTeFJRc+dZDFy4Z+yy8D6w= at revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7, 3 files changed: +dir02/test.txt, -removed.txt, ~dir01/test.txt"),
+			},
+		},
+
+		"Will read include rules from the artifact": {
+			Now: now,
+			Resource: includeFromMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Include("/dir01/test.txt")
+					d.IncludeFrom("dir02/.monorepo-include")
+				}).DieReleasePtr(),
+
+			ExpectResource: includeFromMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Include("/dir01/test.txt")
+					d.IncludeFrom("dir02/.monorepo-include")
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(readyConditions("GitRepository flux-system/mono", "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", 3, "h1:A/DuBnz8ask8oHsd+MvQ3jNZJFtve+KMWktjxtQ6MMc=")...)
+					d.Artifact(&v1alpha1.Artifact{
+						Path:           includeFromArtifactPath,
+						URL:            "http://localhost:9090/" + includeFromArtifactPath,
+						Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+						Checksum:       "h1:A/DuBnz8ask8oHsd+MvQ3jNZJFtve+KMWktjxtQ6MMc=",
+						Digest:         "sha256:63e76a7e28b225559be7f46008e2dfc7eee49b9539f92312b0931374837bf720",
+						LastUpdateTime: metav1.NewTime(now),
+						Size:           ptr.To(int64(175)),
+					})
+					d.FileManifest(fileManifest(includeFromArtifactPath, 3))
+					d.URL("http://localhost:9090/" + includeFromArtifactPath)
+					d.ObservedFileList("dir01/test.txt\ndir02/.monorepo-include\ndir02/test.txt")
+					d.ObservedInclude("# spec.include\n/dir01/test.txt\n# dir02/.monorepo-include\n/dir02\n!*.md\n")
+					d.ChangedFiles(&v1alpha1.ChangedFiles{
+						Count: 3,
+						Added: []string{"dir01/test.txt", "dir02/.monorepo-include", "dir02/test.txt"},
+					})
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, includeFromMonoRepo.DieReleasePtr(), scheme),
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(includeFromMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "ArtifactChanged", "Filtered artifact changed from none to h1:A/DuBnz8ask8oHsd+MvQ3jNZJFtve+KMWktjxtQ6MMc= at revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7, 3 files changed: +dir01/test.txt, +dir02/.monorepo-include, +dir02/test.txt"),
+			},
+		},
+
+		"Will fail when the include file is not in the artifact": {
+			Resource: includeFromMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.IncludeFrom("services/missing/.monorepo-include")
+				}).DieReleasePtr(),
+
+			ExpectResource: includeFromMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.IncludeFrom("services/missing/.monorepo-include")
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionArtifactDownloadedBlank.True().Reason("Succeeded").Message("Downloaded artifact for revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+						resources.MonoRepositoryConditionArtifactFilteredBlank.False().Reason("IncludeFileNotFound").Message("include file services/missing/.monorepo-include not found in artifact"),
+						resources.MonoRepositoryConditionBlank.False().Reason("IncludeFileNotFound").Message("include file services/missing/.monorepo-include not found in artifact"),
+						resources.MonoRepositoryConditionSourceReadyBlank.True().Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, includeFromMonoRepo.DieReleasePtr(), scheme),
+			},
+		},
+
+		"Will fail when the include rules match no files": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Include("/servces/foo")
+					d.Includes("/docs")
+					d.EmptyMatchPolicy(v1alpha1.EmptyMatchPolicyFail)
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+					d.Include("/servces/foo")
+					d.Includes("/docs")
+					d.EmptyMatchPolicy(v1alpha1.EmptyMatchPolicyFail)
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionArtifactDownloadedBlank.True().Reason("Succeeded").Message("Downloaded artifact for revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+						resources.MonoRepositoryConditionArtifactFilteredBlank.False().Reason("NoFilesMatched").Message("include rules did not match any of the 3 files in the artifact"),
+						resources.MonoRepositoryConditionBlank.False().Reason("NoFilesMatched").Message("include rules did not match any of the 3 files in the artifact"),
+						resources.MonoRepositoryConditionSourceReadyBlank.True().Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.UnmatchedIncludes("/servces/foo", "/docs")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NoFilesMatched", "include rules did not match any of the 3 files in the artifact"),
+			},
+		},
+
+		"Will warn when the artifact can not be downloaded": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "missing",
+						Namespace: "flux-system",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "missing",
+						Namespace: "flux-system",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionArtifactDownloadedBlank.False().Reason("DownloadFailed").Message("unable to download http://localhost:8080/missing.tar.gz: 404 Not Found"),
+						resources.MonoRepositoryConditionBlank.False().Reason("DownloadFailed").Message("unable to download http://localhost:8080/missing.tar.gz: 404 Not Found"),
+						resources.MonoRepositoryConditionSourceReadyBlank.True().Reason("Succeeded").Message("GitRepository flux-system/missing is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				missingGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(missingGitRepository, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "DownloadFailed", "unable to download http://localhost:8080/missing.tar.gz: 404 Not Found"),
+			},
+		},
+
+		"Will fail when more than one source is specified": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.GitRepository(&apiv1beta2.GitRepositorySpec{
+						URL: "https://github.com/org/repo",
+					})
+					d.OCIRepository(&apiv1beta2.OCIRepositorySpec{
+						URL: "oci://ghcr.io/org/repo",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(resources.MonoRepositoryConditionBlank.Status("False").Reason("Failed").Message("exactly one of gitRepository, ociRepository, bucket or sourceRef must be specified")).DieReleasePtr()
+				}).DieReleasePtr(),
+
+			ShouldErr: true,
+		},
+	}
+
+	// the artifact for the "nothing to update" case has already been stored
+	_, _, err = s.ArchiveFiles(artifactPath, "testdata", nil)
+	assert.NoError(t, err)
+
+	for name, o := range map[string]controller.Options{
+		"cached":    {Storage: s, Cache: artifactCache, AllowCrossNamespaceRefs: true},
+		"streaming": {Storage: s, AllowCrossNamespaceRefs: true},
+	} {
+		o := o
+		t.Run(name, func(t *testing.T) {
+			ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+				return controller.NewResourceValidator(c, o)
+			})
+		})
+	}
+}
+
+func TestMonoRepositoryFinalizer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta2.AddToScheme(scheme))
+
+	now := metav1.NewTime(time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC))
+
+	deletedMonoRepo := resources.MonoRepositoryBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("deleted-repository")
+			d.Namespace("dev")
+			d.DeletionTimestamp(&now)
+		})
+
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	deletedDir := s.ArtifactPath("MonoRepository", "dev", "deleted-repository", "")
+	otherDir := s.ArtifactPath("MonoRepository", "dev", "other-repository", "")
+
+	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepository]{
+		"Will remove the stored artifacts of a deleted MonoRepository": {
+			Resource: deletedMonoRepo.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.Finalizers(controller.MonoRepositoryFinalizer)
+				}).DieReleasePtr(),
+
+			ExpectResource: deletedMonoRepo.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.ResourceVersion("1000")
+				}).DieReleasePtr(),
+
+			Prepare: func(t *testing.T, ctx context.Context, tc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository]) (context.Context, error) {
+				for _, path := range []string{
+					deletedDir + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.tar.gz",
+					deletedDir + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.manifest",
+					deletedDir + "components/one/3960c6299b88c13995815300648c8ded6372582aee232c9a5836bb3e8acf68d8.tar.gz",
+					otherDir + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.tar.gz",
+				} {
+					if err := s.WriteFile(path, []byte("artifact")); err != nil {
+						return nil, err
+					}
+				}
+				return ctx, nil
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(deletedMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeNormal, "FinalizerPatched", "Patched finalizer %q", controller.MonoRepositoryFinalizer),
+			},
+
+			ExpectPatches: []rtesting.PatchRef{
+				{
+					Group:     "source.garethjevans.org",
+					Kind:      "MonoRepository",
+					Namespace: "dev",
+					Name:      "deleted-repository",
+					PatchType: types.MergePatchType,
+					Patch:     []byte(`{"metadata":{"finalizers":null,"resourceVersion":"999"}}`),
+				},
+			},
+
+			Verify: func(t *testing.T, result reconcilers.Result, err error) {
+				_, statErr := os.Stat(s.LocalPath(deletedDir))
+				assert.True(t, os.IsNotExist(statErr), "the artifacts of the deleted MonoRepository should be removed")
+				assert.True(t, s.Exists(otherDir+"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.tar.gz"))
+			},
+		},
+
+		"Will ignore a deleted MonoRepository without stored artifacts": {
+			Resource: deletedMonoRepo.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.Name("never-reconciled")
+					d.Finalizers(controller.MonoRepositoryFinalizer)
+				}).DieReleasePtr(),
+
+			ExpectResource: deletedMonoRepo.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.Name("never-reconciled")
+					d.ResourceVersion("1000")
+				}).DieReleasePtr(),
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(deletedMonoRepo.MetadataDie(func(d *v1.ObjectMetaDie) { d.Name("never-reconciled") }).DieReleasePtr(), scheme, corev1.EventTypeNormal, "FinalizerPatched", "Patched finalizer %q", controller.MonoRepositoryFinalizer),
+			},
+
+			ExpectPatches: []rtesting.PatchRef{
+				{
+					Group:     "source.garethjevans.org",
+					Kind:      "MonoRepository",
+					Namespace: "dev",
+					Name:      "never-reconciled",
+					PatchType: types.MergePatchType,
+					Patch:     []byte(`{"metadata":{"finalizers":null,"resourceVersion":"999"}}`),
+				},
+			},
+		},
+	}
+
+	ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+		return controller.NewMonoRepositoryReconciler(c, controller.Options{Storage: s}).Reconciler
+	})
+}
+
+func TestMonoRepositoryWatchNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta2.AddToScheme(scheme))
+
+	baseMonoRepo := resources.MonoRepositoryBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("mono-repository")
+			d.Namespace("dev")
+		})
+
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepository]{
+		"Will report a source in a namespace that is not watched": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceOutOfScope").Message("GitRepository flux-system/mono is in a namespace that is not watched by the controller"),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceOutOfScope").Message("GitRepository flux-system/mono is in a namespace that is not watched by the controller"),
+					)
+				}).DieReleasePtr(),
+		},
+
+		"Will track a source in a watched namespace": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind: "GitRepository",
+						Name: "missing",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind: "GitRepository",
+						Name: "missing",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceNotFound").Message("GitRepository dev/missing not found"),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceNotFound").Message("GitRepository dev/missing not found"),
+					)
+				}).DieReleasePtr(),
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "missing",
+						Namespace: "dev",
+					},
+				}, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+		},
+	}
+
+	// the manager cache is limited to the dev namespace
+	o := controller.Options{Storage: s, WatchNamespaces: []string{"dev"}, AllowCrossNamespaceRefs: true}
+	ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+		return controller.NewResourceValidator(c, o)
+	})
+}
+
+func TestMonoRepositoryCrossNamespaceRefs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta2.AddToScheme(scheme))
+
+	baseMonoRepo := resources.MonoRepositoryBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("mono-repository")
+			d.Namespace("dev")
+		})
+
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepository]{
+		"Will not read a source in another namespace": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "mono",
+						Namespace: "flux-system",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceAccessDenied").Message("GitRepository flux-system/mono is in another namespace and cross-namespace references are not allowed"),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceAccessDenied").Message("GitRepository flux-system/mono is in another namespace and cross-namespace references are not allowed"),
+					)
+				}).DieReleasePtr(),
+		},
+
+		"Will track a source in the same namespace": {
+			Resource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "missing",
+						Namespace: "dev",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseMonoRepo.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.SourceRef(&v1alpha1.SourceReference{
+						Kind:      "GitRepository",
+						Name:      "missing",
+						Namespace: "dev",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositoryConditionBlank.False().Reason("SourceNotFound").Message("GitRepository dev/missing not found"),
+						resources.MonoRepositoryConditionSourceReadyBlank.False().Reason("SourceNotFound").Message("GitRepository dev/missing not found"),
+					)
+				}).DieReleasePtr(),
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "missing",
+						Namespace: "dev",
+					},
+				}, baseMonoRepo.DieReleasePtr(), scheme),
+			},
+		},
+	}
+
+	// cross-namespace references are not allowed by default
+	o := controller.Options{Storage: s}
+	ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+		return controller.NewResourceValidator(c, o)
+	})
+}
+
+func TestMonoRepositoryChangeNotifier(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	notifications := notificationServer(t)
+
+	baseMonoRepo := resources.MonoRepositoryBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("mono-repository")
+			d.Namespace("dev")
+		}).
+		SpecDie(func(d *resources.MonoRepositorySpecDie) {
+			d.Notify(&v1alpha1.Notify{
+				FluxEventsAddress: notifications.URL + "/flux",
+				Webhooks: []v1alpha1.Webhook{
+					{URL: notifications.URL + "/signed", SecretRef: &meta.LocalObjectReference{Name: "webhook-token"}},
+					{URL: notifications.URL + "/broken"},
+				},
+			})
+		})
+
+	// the artifact has been recorded in the status by a previous reconcile
+	recordedMonoRepo := baseMonoRepo.
+		StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+			d.Artifact(&v1alpha1.Artifact{
+				Path:           "monorepository/dev/mono-repository/artifact.tar.gz",
+				URL:            "http://localhost:9090/monorepository/dev/mono-repository/artifact.tar.gz",
+				Revision:       "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+				Checksum:       "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+				Digest:         "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+				LastUpdateTime: metav1.NewTime(time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC)),
+			})
+			d.ChangedFiles(&v1alpha1.ChangedFiles{Count: 1, Modified: []string{"dir01/test.txt"}})
+			d.NotifiedChecksum("h1:previous")
+		})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook-token", Namespace: "dev"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+
+	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepository]{
+		"Will notify a recorded artifact once": {
+			Resource: recordedMonoRepo.DieReleasePtr(),
+
+			ExpectResource: recordedMonoRepo.
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.NotifiedChecksum("h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+				}).DieReleasePtr(),
+
+			APIGivenObjects: []client.Object{
+				secret,
+			},
+
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(recordedMonoRepo.DieReleasePtr(), scheme, corev1.EventTypeWarning, "NotificationFailed", "Unable to notify %s/broken: unexpected response status 500 Internal Server Error", notifications.URL),
+			},
+		},
+
+		"Will not notify an artifact again": {
+			Resource: recordedMonoRepo.
+				StatusDie(func(d *resources.MonoRepositoryStatusDie) {
+					d.NotifiedChecksum("h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=")
+				}).DieReleasePtr(),
+
+			APIGivenObjects: []client.Object{
+				secret,
+			},
+		},
+
+		"Will not notify without an artifact": {
+			Resource: baseMonoRepo.DieReleasePtr(),
+		},
+	}
+
+	// every change is also sent as a CloudEvent, a rejected event would be
+	// reported with a NotificationFailed event
+	cloudEvents, err := notify.NewCloudEventsSink(notifications.URL+"/cloudevents", notify.BinaryMode)
+	assert.NoError(t, err)
+
+	o := controller.Options{CloudEvents: cloudEvents}
+	ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepository], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+		return controller.NewChangeNotifier(c, o)
+	})
+}
+
+// notificationServer accepts notifications that are signed with the token of
+// the webhook-token secret, or not signed at all, the broken webhook always
+// fails.
+func notificationServer(t *testing.T) *httptest.Server {
+	notifications := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/flux":
+			var event notify.Event
+			if err := json.Unmarshal(body, &event); err != nil || event.Reason != "ArtifactChanged" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		case "/cloudevents":
+			if r.Header.Get("Ce-Type") != notify.ArtifactChangedType {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "/signed":
+			if r.Header.Get(notify.SignatureHeader) != notify.Sign([]byte("s3cr3t"), body) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(notifications.Close)
+	return notifications
 }
@@ -0,0 +1,326 @@
+/*
+Copyright 2023 VMware Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/defaulting"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+)
+
+//+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositorysets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositorysets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=source.garethjevans.org,resources=monorepositorysets/finalizers,verbs=update
+
+// DirectoryAnnotation records the directory a MonoRepository was generated for.
+const DirectoryAnnotation = "source.garethjevans.org/directory"
+
+const desiredMonoRepositoriesStashKey reconcilers.StashKey = "source.garethjevans.org:desired-monorepositories"
+
+func NewMonoRepositorySetReconciler(c reconcilers.Config, o Options) *reconcilers.ResourceReconciler[*v1alpha1.MonoRepositorySet] {
+	return &reconcilers.ResourceReconciler[*v1alpha1.MonoRepositorySet]{
+		Name: "MonoRepositorySet",
+		Reconciler: reconcilers.Sequence[*v1alpha1.MonoRepositorySet]{
+			NewDirectoryGenerator(c, o),
+			NewMonoRepositoryChildSetReconciler(c),
+		},
+		Config: c,
+	}
+}
+
+// NewDirectoryGenerator selects the directories of the referenced source that
+// match the generator and renders the MonoRepository for each of them. The
+// remaining reconcilers are halted while the source is not ready so that the
+// existing MonoRepositories are kept.
+func NewDirectoryGenerator(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepositorySet] {
+	return &reconcilers.SyncReconciler[*v1alpha1.MonoRepositorySet]{
+		Name: "DirectoryGenerator",
+		Setup: func(ctx context.Context, mgr ctrl.Manager, bldr *builder.Builder) error {
+			bldr.Watches(&apiv1beta2.GitRepository{}, reconcilers.EnqueueTracked(ctx))
+			bldr.Watches(&apiv1beta2.OCIRepository{}, reconcilers.EnqueueTracked(ctx))
+			bldr.Watches(&apiv1beta2.Bucket{}, reconcilers.EnqueueTracked(ctx))
+			return nil
+		},
+		Sync: func(ctx context.Context, parent *v1alpha1.MonoRepositorySet) error {
+			ref := parent.Spec.SourceRef
+			src, key, err := getSource(ctx, c, o, parent.Namespace, ref, &parent.Status)
+			if err != nil {
+				return err
+			}
+			if src == nil {
+				return reconcilers.ErrHaltSubReconcilers
+			}
+
+			name := fmt.Sprintf("%s %s", ref.Kind, key)
+			artifact := src.GetArtifact()
+			if !isReady(src) || artifact == nil {
+				parent.Status.MarkSourceNotReady(ctx, name, apimeta.FindStatusCondition(src.GetConditions(), meta.ReadyCondition))
+				return reconcilers.ErrHaltSubReconcilers
+			}
+			parent.Status.MarkSourceReady(ctx, name, artifact.Revision)
+
+			snap, err := openSnapshot(ctx, o, artifact, nil)
+			if err != nil {
+				parent.Status.MarkFailed(ctx, err)
+				return reconcilers.ErrHaltSubReconcilers
+			}
+			defer snap.Close()
+
+			files, err := snap.Files()
+			if err != nil {
+				parent.Status.MarkFailed(ctx, err)
+				return reconcilers.ErrHaltSubReconcilers
+			}
+
+			dirs, err := util.MatchDirectories(files, parent.Spec.Generator.Directories, parent.Spec.Generator.Containing)
+			if err != nil {
+				parent.Status.MarkFailed(ctx, fmt.Errorf("invalid directories pattern: %w", err))
+				return reconcilers.ErrHaltSubReconcilers
+			}
+
+			children := make([]*v1alpha1.MonoRepository, 0, len(dirs))
+			for _, dir := range dirs {
+				child, err := renderMonoRepository(parent, dir)
+				if err != nil {
+					parent.Status.MarkFailed(ctx, fmt.Errorf("unable to render template for %s: %w", dir, err))
+					return reconcilers.ErrHaltSubReconcilers
+				}
+				if err := selectChild(o.Selector, child); err != nil {
+					parent.Status.MarkFailed(ctx, fmt.Errorf("unable to render template for %s: %w", dir, err))
+					return reconcilers.ErrHaltSubReconcilers
+				}
+				children = append(children, child)
+			}
+
+			reconcilers.StashValue(ctx, desiredMonoRepositoriesStashKey, children)
+			parent.Status.Directories = dirs
+			parent.Status.ObservedRevision = artifact.Revision
+
+			return nil
+		},
+	}
+}
+
+// NewMonoRepositoryChildSetReconciler creates, updates and prunes the
+// MonoRepositories rendered by the DirectoryGenerator.
+func NewMonoRepositoryChildSetReconciler(c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepositorySet] {
+	return &reconcilers.ChildSetReconciler[*v1alpha1.MonoRepositorySet, *v1alpha1.MonoRepository, *v1alpha1.MonoRepositoryList]{
+		Name: "MonoRepositories",
+		DesiredChildren: func(ctx context.Context, parent *v1alpha1.MonoRepositorySet) ([]*v1alpha1.MonoRepository, error) {
+			children, _ := reconcilers.RetrieveValue(ctx, desiredMonoRepositoriesStashKey).([]*v1alpha1.MonoRepository)
+			return children, nil
+		},
+		IdentifyChild: func(child *v1alpha1.MonoRepository) string {
+			return child.Annotations[DirectoryAnnotation]
+		},
+		MergeBeforeUpdate: func(actual, desired *v1alpha1.MonoRepository) {
+			actual.Labels = desired.Labels
+			actual.Annotations = reconcilers.MergeMaps(actual.Annotations, desired.Annotations)
+			actual.Spec = desired.Spec
+		},
+		ReflectChildrenStatusOnParent: func(ctx context.Context, parent *v1alpha1.MonoRepositorySet, result reconcilers.ChildSetResult[*v1alpha1.MonoRepository]) {
+			if err := result.AggregateError(); err != nil {
+				parent.Status.MarkFailed(ctx, err)
+				return
+			}
+			parent.Status.MarkReady(ctx, len(parent.Status.Directories))
+		},
+		Sanitize: func(child *v1alpha1.MonoRepository) any {
+			return child.Spec
+		},
+	}
+}
+
+// selectChild adds the labels the selector of the manager requires to the
+// child, so that it is in the manager cache and seen by the ChildSetReconciler.
+// Labels of the template are kept, a child they rule out is an error rather
+// than one that would be created again on every reconcile.
+func selectChild(selector labels.Selector, child *v1alpha1.MonoRepository) error {
+	if selector == nil || selector.Empty() {
+		return nil
+	}
+
+	required := map[string]string{}
+	requirements, _ := selector.Requirements()
+	for _, r := range requirements {
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			if values := r.Values(); values.Len() == 1 {
+				required[r.Key()] = values.List()[0]
+			}
+		}
+	}
+	child.Labels = reconcilers.MergeMaps(required, child.Labels)
+
+	if !selector.Matches(labels.Set(child.Labels)) {
+		return fmt.Errorf("labels %v do not match the selector %q of the manager", labels.Set(child.Labels), selector)
+	}
+	return nil
+}
+
+// templateData is passed to the templates of a MonoRepositorySet.
+type templateData struct {
+	// Path is the slash separated path of the directory.
+	Path string
+	// Name is the base name of the directory.
+	Name string
+}
+
+// renderMonoRepository renders the template of the set for the directory, with
+// the defaults the defaulting webhook would set.
+func renderMonoRepository(parent *v1alpha1.MonoRepositorySet, dir string) (*v1alpha1.MonoRepository, error) {
+	data := templateData{Path: dir, Name: path.Base(dir)}
+	t := parent.Spec.Template
+
+	labels, err := renderMap(t.Labels, data)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := renderMap(t.Annotations, data)
+	if err != nil {
+		return nil, err
+	}
+	include, err := renderTemplate(t.Include, data)
+	if err != nil {
+		return nil, err
+	}
+	includeFrom, err := renderTemplate(t.IncludeFrom, data)
+	if err != nil {
+		return nil, err
+	}
+	includes, err := renderTemplates(t.Includes, data)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := renderTemplates(t.Exclude, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := parent.Spec.SourceRef
+	if ref.Namespace == "" {
+		ref.Namespace = parent.Namespace
+	}
+
+	child := &v1alpha1.MonoRepository{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        childName(parent.Name, dir),
+			Namespace:   parent.Namespace,
+			Labels:      labels,
+			Annotations: reconcilers.MergeMaps(annotations, map[string]string{DirectoryAnnotation: dir}),
+		},
+		Spec: v1alpha1.MonoRepositorySpec{
+			SourceRef:   &ref,
+			Include:     include,
+			IncludeFrom: includeFrom,
+			Includes:    includes,
+			Exclude:     exclude,
+		},
+	}
+
+	// the defaulting webhook would otherwise change every update of the child,
+	// which the set would then revert
+	defaulting.Apply(child)
+	return child, nil
+}
+
+func renderTemplate(text string, data templateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderTemplates(texts []string, data templateData) ([]string, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	rendered := make([]string, 0, len(texts))
+	for _, text := range texts {
+		s, err := renderTemplate(text, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, s)
+	}
+	return rendered, nil
+}
+
+func renderMap(values map[string]string, data templateData) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	rendered := make(map[string]string, len(values))
+	for k, v := range values {
+		s, err := renderTemplate(v, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered[k] = s
+	}
+	return rendered, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// maxChildNameLength keeps the name of a generated MonoRepository, and of the
+// resources named after it, a valid DNS-1123 label.
+const maxChildNameLength = validation.DNS1123LabelMaxLength
+
+// childName returns the name of the MonoRepository generated for dir. The
+// directory is reduced to the characters allowed in a resource name and a
+// short hash of the directory is appended, so that directories that reduce to
+// the same characters, e.g. apps/foo and apps_foo, do not collide. Long names
+// are truncated before the hash.
+func childName(parent, dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	hash := hex.EncodeToString(sum[:])[:8]
+
+	name := parent + "-" + strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(dir), "-"), "-")
+	if len(name) > maxChildNameLength-len(hash)-1 {
+		name = name[:maxChildNameLength-len(hash)-1]
+	}
+	return strings.TrimRight(name, "-") + "-" + hash
+}
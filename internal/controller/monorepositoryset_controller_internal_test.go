@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestChildName(t *testing.T) {
+	assert.Equal(t, "services-dir01-f75db129", childName("services", "dir01"))
+
+	// directories reduced to the same characters still have different names
+	assert.NotEqual(t, childName("services", "apps/foo"), childName("services", "apps_foo"))
+	assert.True(t, strings.HasPrefix(childName("services", "apps/foo"), "services-apps-foo-"))
+
+	// long directories are truncated to a valid name, never ending in a dash
+	long := childName("services", strings.Repeat("a", 45)+"/"+strings.Repeat("b", 20))
+	assert.Len(t, long, validation.DNS1123LabelMaxLength)
+	assert.Empty(t, validation.IsDNS1123Label(long))
+
+	dashed := childName("services", strings.Repeat("a", 44)+"/b")
+	assert.Empty(t, validation.IsDNS1123Label(dashed))
+	assert.NotContains(t, dashed, "--")
+}
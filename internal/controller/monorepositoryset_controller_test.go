@@ -0,0 +1,530 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/cache"
+	"github.com/garethjevans/monorepository-controller/internal/controller"
+	"github.com/garethjevans/monorepository-controller/internal/defaulting"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/garethjevans/monorepository-controller/internal/tests/resources"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	apiv1beta1 "github.com/fluxcd/source-controller/api/v1beta1"
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	v1 "dies.dev/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestMonoRepositorySet(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	// mono repository
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	// flux
+	utilruntime.Must(apiv1beta1.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta2.AddToScheme(scheme))
+	utilruntime.Must(apiv1.AddToScheme(scheme))
+
+	sourceRef := v1alpha1.SourceReference{
+		Kind:      "GitRepository",
+		Name:      "mono",
+		Namespace: "flux-system",
+	}
+
+	baseSet := resources.MonoRepositorySetBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("services")
+			d.Namespace("dev")
+		}).
+		SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+			d.SourceRef(sourceRef)
+			d.Generator(v1alpha1.DirectoryGenerator{
+				Directories: "dir*",
+			})
+			d.Template(v1alpha1.MonoRepositoryTemplate{
+				Labels: map[string]string{
+					"app": "{{ .Name }}",
+				},
+				Include: "/{{ .Path }}",
+			})
+		})
+
+	ServeDir(t, "testdata")
+
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	artifactCache, err := cache.New(t.TempDir(), "", 1024*1024)
+	assert.NoError(t, err)
+
+	referencedGitRepository := &apiv1beta2.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mono",
+			Namespace: "flux-system",
+		},
+		Spec: apiv1beta2.GitRepositorySpec{
+			URL: "https://github.com/org/repo",
+		},
+		Status: apiv1beta2.GitRepositoryStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:    "Ready",
+					Status:  "True",
+					Reason:  "Succeeded",
+					Message: "stored artifact for revision 'main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7'",
+				},
+			},
+			Artifact: &apiv1.Artifact{
+				Path:     "gitrepository/flux-system/mono/68d842cd330410cf0672f862d9a799af4dcdc1d7.tar.gz",
+				URL:      "http://localhost:8080/file.tar.gz",
+				Revision: "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+				Digest:   "sha256:889c03dea61a629f2f39c2669f08889cb92173a597e41c9da1d471ec2193f536",
+			},
+		},
+	}
+
+	child := func(name, dir string) *resources.MonoRepositoryDie {
+		return resources.MonoRepositoryBlank.
+			MetadataDie(func(d *v1.ObjectMetaDie) {
+				d.Name(name)
+				d.Namespace("dev")
+				d.AddLabel("app", dir)
+				d.AddAnnotation(controller.DirectoryAnnotation, dir)
+				d.ControlledBy(baseSet.DieReleasePtr(), scheme)
+			}).
+			SpecDie(func(d *resources.MonoRepositorySpecDie) {
+				d.SourceRef(&sourceRef)
+				d.Include("/" + dir)
+			})
+	}
+
+	// a template without include rules, the generated MonoRepositories select
+	// every file
+	defaultedSet := baseSet.
+		SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+			d.Generator(v1alpha1.DirectoryGenerator{
+				Directories: "dir*",
+				Containing:  ".monorepo-include",
+			})
+			d.Template(v1alpha1.MonoRepositoryTemplate{})
+		})
+	defaultedChild := resources.MonoRepositoryBlank.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("services-dir02-532b3420")
+			d.Namespace("dev")
+			d.AddAnnotation(controller.DirectoryAnnotation, "dir02")
+			d.AddAnnotation(defaulting.DefaultedAnnotation, "spec.include")
+			d.ControlledBy(defaultedSet.DieReleasePtr(), scheme)
+		}).
+		SpecDie(func(d *resources.MonoRepositorySpecDie) {
+			d.SourceRef(&sourceRef)
+			d.Include(defaulting.DefaultInclude)
+		})
+
+	ts := rtesting.SubReconcilerTests[*v1alpha1.MonoRepositorySet]{
+		"Will generate a MonoRepository for each directory": {
+			Resource: baseSet.DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 2 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir01", "dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ExpectCreates: []client.Object{
+				child("services-dir01-f75db129", "dir01").DieReleasePtr(),
+				child("services-dir02-532b3420", "dir02").DieReleasePtr(),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Created", "Created MonoRepository %q", "services-dir01-f75db129"),
+				rtesting.NewEvent(baseSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Created", "Created MonoRepository %q", "services-dir02-532b3420"),
+			},
+		},
+
+		"Will only select directories containing a file and prune the others": {
+			Resource: baseSet.
+				SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+					d.Generator(v1alpha1.DirectoryGenerator{
+						Directories: "dir*",
+						Containing:  ".monorepo-include",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+					d.Generator(v1alpha1.DirectoryGenerator{
+						Directories: "dir*",
+						Containing:  ".monorepo-include",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 1 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+				child("services-dir01-f75db129", "dir01"),
+				child("services-dir02-532b3420", "dir02"),
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ExpectDeletes: []rtesting.DeleteRef{
+				rtesting.NewDeleteRefFromObject(child("services-dir01-f75db129", "dir01"), scheme),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(baseSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Deleted", "Deleted MonoRepository %q", "services-dir01-f75db129"),
+			},
+		},
+
+		"Will render the defaults of the generated MonoRepositories": {
+			Resource: defaultedSet.DieReleasePtr(),
+
+			ExpectResource: defaultedSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 1 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, defaultedSet.DieReleasePtr(), scheme),
+			},
+			ExpectCreates: []client.Object{
+				defaultedChild.DieReleasePtr(),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(defaultedSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Created", "Created MonoRepository %q", "services-dir02-532b3420"),
+			},
+		},
+
+		"Will not revert the defaults of the generated MonoRepositories": {
+			Resource: defaultedSet.DieReleasePtr(),
+
+			ExpectResource: defaultedSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 1 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+				// as defaulted by the webhook when it was created
+				defaultedChild,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, defaultedSet.DieReleasePtr(), scheme),
+			},
+		},
+
+		"Will keep the MonoRepositories while the source is not ready": {
+			Resource: baseSet.DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("Unknown").Reason("SourceNotReady").Message("waiting for GitRepository flux-system/mono to become ready"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("Unknown").Reason("SourceNotReady").Message("waiting for GitRepository flux-system/mono to become ready"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: referencedGitRepository.ObjectMeta,
+					Spec:       referencedGitRepository.Spec,
+				},
+				child("services-dir01-f75db129", "dir01"),
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ShouldErr: true,
+		},
+
+		"Will wait for a ready source without an artifact": {
+			Resource: baseSet.DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("Unknown").Reason("SourceNotReady").Message("GitRepository flux-system/mono does not have an artifact"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("Unknown").Reason("SourceNotReady").Message("GitRepository flux-system/mono does not have an artifact"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: referencedGitRepository.ObjectMeta,
+					Spec:       referencedGitRepository.Spec,
+					Status: apiv1beta2.GitRepositoryStatus{
+						Conditions: referencedGitRepository.Status.Conditions,
+					},
+				},
+				child("services-dir01-f75db129", "dir01"),
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ShouldErr: true,
+		},
+
+		"Will report a source that does not exist": {
+			Resource: baseSet.DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("False").Reason("SourceNotFound").Message("GitRepository flux-system/mono not found"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("False").Reason("SourceNotFound").Message("GitRepository flux-system/mono not found"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				child("services-dir01-f75db129", "dir01"),
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ShouldErr: true,
+		},
+
+		"Will fail when the template is invalid": {
+			Resource: baseSet.
+				SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+					d.Template(v1alpha1.MonoRepositoryTemplate{
+						Include: "/{{ .Missing }}",
+					})
+				}).DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+					d.Template(v1alpha1.MonoRepositoryTemplate{
+						Include: "/{{ .Missing }}",
+					})
+				}).
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("False").Reason("Failed").Message(`unable to render template for dir01: template: :1:4: executing "" at <.Missing>: can't evaluate field Missing in type controller.templateData`),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, baseSet.DieReleasePtr(), scheme),
+			},
+			ShouldErr: true,
+		},
+	}
+
+	for name, o := range map[string]controller.Options{
+		"cached":    {Storage: s, Cache: artifactCache, AllowCrossNamespaceRefs: true},
+		"streaming": {Storage: s, AllowCrossNamespaceRefs: true},
+	} {
+		o := o
+		t.Run(name, func(t *testing.T) {
+			ts.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepositorySet], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepositorySet] {
+				return reconcilers.Sequence[*v1alpha1.MonoRepositorySet]{
+					controller.NewDirectoryGenerator(c, o),
+					controller.NewMonoRepositoryChildSetReconciler(c),
+				}
+			})
+		})
+	}
+
+	// a manager started with --selector=shard=a only has the MonoRepositories
+	// matching the selector in its cache
+	selector, err := labels.Parse("shard=a")
+	assert.NoError(t, err)
+
+	shardedSet := baseSet.
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.AddLabel("shard", "a")
+		})
+	shardedChild := func(name, dir string) *resources.MonoRepositoryDie {
+		return resources.MonoRepositoryBlank.
+			MetadataDie(func(d *v1.ObjectMetaDie) {
+				d.Name(name)
+				d.Namespace("dev")
+				d.AddLabel("app", dir)
+				d.AddLabel("shard", "a")
+				d.AddAnnotation(controller.DirectoryAnnotation, dir)
+				d.ControlledBy(shardedSet.DieReleasePtr(), scheme)
+			}).
+			SpecDie(func(d *resources.MonoRepositorySpecDie) {
+				d.SourceRef(&sourceRef)
+				d.Include("/" + dir)
+			})
+	}
+	conflictingSet := shardedSet.
+		SpecDie(func(d *resources.MonoRepositorySetSpecDie) {
+			d.Template(v1alpha1.MonoRepositoryTemplate{
+				Labels: map[string]string{
+					"shard": "b",
+				},
+				Include: "/{{ .Path }}",
+			})
+		})
+
+	selectorTs := rtesting.SubReconcilerTests[*v1alpha1.MonoRepositorySet]{
+		"Will label the generated MonoRepositories to match the selector": {
+			Resource: shardedSet.DieReleasePtr(),
+
+			ExpectResource: shardedSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 2 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir01", "dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, shardedSet.DieReleasePtr(), scheme),
+			},
+			ExpectCreates: []client.Object{
+				shardedChild("services-dir01-f75db129", "dir01").DieReleasePtr(),
+				shardedChild("services-dir02-532b3420", "dir02").DieReleasePtr(),
+			},
+			ExpectEvents: []rtesting.Event{
+				rtesting.NewEvent(shardedSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Created", "Created MonoRepository %q", "services-dir01-f75db129"),
+				rtesting.NewEvent(shardedSet.DieReleasePtr(), scheme, corev1.EventTypeNormal, "Created", "Created MonoRepository %q", "services-dir02-532b3420"),
+			},
+		},
+
+		"Will not recreate the labelled MonoRepositories": {
+			Resource: shardedSet.DieReleasePtr(),
+
+			ExpectResource: shardedSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("True").Reason("Succeeded").Message("Generated 2 MonoRepositories"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+					d.Directories("dir01", "dir02")
+					d.ObservedRevision("main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7")
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+				shardedChild("services-dir01-f75db129", "dir01"),
+				shardedChild("services-dir02-532b3420", "dir02"),
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, shardedSet.DieReleasePtr(), scheme),
+			},
+		},
+
+		"Will fail when the template labels do not match the selector": {
+			Resource: conflictingSet.DieReleasePtr(),
+
+			ExpectResource: conflictingSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("False").Reason("Failed").Message(`unable to render template for dir01: labels shard=b do not match the selector "shard=a" of the manager`),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("True").Reason("Succeeded").Message("GitRepository flux-system/mono is ready with revision main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+			},
+
+			ExpectTracks: []rtesting.TrackRequest{
+				rtesting.NewTrackRequest(referencedGitRepository, conflictingSet.DieReleasePtr(), scheme),
+			},
+			ShouldErr: true,
+		},
+	}
+
+	selectorTs.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepositorySet], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepositorySet] {
+		o := controller.Options{Storage: s, Cache: artifactCache, AllowCrossNamespaceRefs: true, Selector: selector}
+		return reconcilers.Sequence[*v1alpha1.MonoRepositorySet]{
+			controller.NewDirectoryGenerator(c, o),
+			controller.NewMonoRepositoryChildSetReconciler(c),
+		}
+	})
+
+	deniedTs := rtesting.SubReconcilerTests[*v1alpha1.MonoRepositorySet]{
+		"Will not read a source in another namespace": {
+			Resource: baseSet.DieReleasePtr(),
+
+			ExpectResource: baseSet.
+				StatusDie(func(d *resources.MonoRepositorySetStatusDie) {
+					d.ConditionsDie(
+						resources.MonoRepositorySetConditionBlank.Status("False").Reason("SourceAccessDenied").Message("GitRepository flux-system/mono is in another namespace and cross-namespace references are not allowed"),
+						resources.MonoRepositorySetConditionSourceReadyBlank.Status("False").Reason("SourceAccessDenied").Message("GitRepository flux-system/mono is in another namespace and cross-namespace references are not allowed"),
+					)
+				}).DieReleasePtr(),
+
+			GivenObjects: []client.Object{
+				referencedGitRepository,
+				child("services-dir01-f75db129", "dir01"),
+			},
+			ShouldErr: true,
+		},
+	}
+
+	deniedTs.Run(t, scheme, func(t *testing.T, rtc *rtesting.SubReconcilerTestCase[*v1alpha1.MonoRepositorySet], c reconcilers.Config) reconcilers.SubReconciler[*v1alpha1.MonoRepositorySet] {
+		o := controller.Options{Storage: s, Cache: artifactCache}
+		return reconcilers.Sequence[*v1alpha1.MonoRepositorySet]{
+			controller.NewDirectoryGenerator(c, o),
+			controller.NewMonoRepositoryChildSetReconciler(c),
+		}
+	})
+}
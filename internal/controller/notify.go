@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	rtime "github.com/vmware-labs/reconciler-runtime/time"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+
+// NewChangeNotifier sends the change to the filtered artifact of the parent to
+// the CloudEvents sink, and the Flux notification-controller and webhooks
+// configured in the spec, once the status holding the new artifact has been
+// updated, which queues the next reconcile. It runs ahead of the reconcilers that may change the artifact so
+// that only an artifact read back from the API server is notified, and records
+// the checksum in NotifiedChecksum so that it is not notified again. A status
+// update that fails after the notifications were sent causes them to be sent
+// again on the next reconcile, delivery is at least once.
+//
+// The artifact has already been stored, so a failure is reported with a Warning
+// event rather than failing the reconcile, and is not retried.
+func NewChangeNotifier(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.SyncReconciler[*v1alpha1.MonoRepository]{
+		Name: "ChangeNotifier",
+		Sync: func(ctx context.Context, parent *v1alpha1.MonoRepository) error {
+			artifact := parent.Status.Artifact
+			if artifact == nil || artifact.Checksum == parent.Status.NotifiedChecksum {
+				return nil
+			}
+
+			previous := parent.Status.NotifiedChecksum
+			old := previous
+			if old == "" {
+				old = "none"
+			}
+			message := fmt.Sprintf("Filtered artifact changed from %s to %s at revision %s, %s", old, artifact.Checksum, artifact.Revision, summarise(parent.Status.ChangedFiles))
+
+			notifyChange(ctx, c, o, parent, previous, message)
+			parent.Status.NotifiedChecksum = artifact.Checksum
+			return nil
+		},
+	}
+}
+
+// notifyChange sends the change to each configured target, a target that cannot
+// be notified is reported with a Warning event.
+func notifyChange(ctx context.Context, c reconcilers.Config, o Options, parent *v1alpha1.MonoRepository, previous, message string) {
+	log := util.L(ctx)
+
+	change := notify.Change{
+		Namespace:        parent.Namespace,
+		Name:             parent.Name,
+		Revision:         parent.Status.Artifact.Revision,
+		Checksum:         parent.Status.Artifact.Checksum,
+		PreviousChecksum: previous,
+		URL:              parent.Status.Artifact.URL,
+		Digest:           parent.Status.Artifact.Digest,
+		ChangedFiles:     parent.Status.ChangedFiles,
+		Message:          message,
+		Timestamp:        parent.Status.Artifact.LastUpdateTime,
+	}
+	if change.Timestamp.IsZero() {
+		change.Timestamp.Time = rtime.RetrieveNow(ctx)
+	}
+
+	failed := func(target string, err error) {
+		log.Error(err, "unable to send notification", "target", target)
+		c.Recorder.Eventf(parent, corev1.EventTypeWarning, "NotificationFailed", "Unable to notify %s: %s", target, err)
+	}
+
+	if o.CloudEvents != nil {
+		if err := o.CloudEvents.Send(ctx, notify.ArtifactChangedEvent(change)); err != nil {
+			failed(o.CloudEvents.URL, err)
+		}
+	}
+
+	n := parent.Spec.Notify
+	if n == nil {
+		return
+	}
+
+	if n.FluxEventsAddress != "" {
+		if err := notify.Flux(ctx, n.FluxEventsAddress, notify.FluxEvent(change, "ArtifactChanged")); err != nil {
+			failed(n.FluxEventsAddress, err)
+		}
+	}
+
+	for _, webhook := range n.Webhooks {
+		token, err := webhookToken(ctx, c, parent, webhook)
+		if err != nil {
+			failed(webhook.URL, err)
+			continue
+		}
+		if err := notify.Webhook(ctx, webhook.URL, token, change); err != nil {
+			failed(webhook.URL, err)
+		}
+	}
+}
+
+// webhookToken returns the token used to sign the payload sent to the webhook,
+// nil when the payload is not signed.
+func webhookToken(ctx context.Context, c reconcilers.Config, parent *v1alpha1.MonoRepository, webhook v1alpha1.Webhook) ([]byte, error) {
+	if webhook.SecretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: parent.Namespace, Name: webhook.SecretRef.Name}
+	if err := c.APIReader.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	token, ok := secret.Data["token"]
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not contain a token", key)
+	}
+	return token, nil
+}
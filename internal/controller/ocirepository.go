@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func NewOCIRepositoryReconciler(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.ChildReconciler[*v1alpha1.MonoRepository, *apiv1beta2.OCIRepository, *apiv1beta2.OCIRepositoryList]{
+		Name: "OCIRepository",
+		DesiredChild: func(ctx context.Context, parent *v1alpha1.MonoRepository) (*apiv1beta2.OCIRepository, error) {
+			if parent.Spec.OCIRepository == nil {
+				return nil, nil
+			}
+
+			child := &apiv1beta2.OCIRepository{
+				ObjectMeta: v1.ObjectMeta{
+					Labels:      FilterLabelsOrAnnotations(reconcilers.MergeMaps(parent.Labels)),
+					Annotations: FilterLabelsOrAnnotations(reconcilers.MergeMaps(parent.Annotations)),
+					Name:        parent.Name,
+					Namespace:   parent.Namespace,
+				},
+				Spec: *parent.Spec.OCIRepository,
+			}
+
+			return child, nil
+		},
+		MergeBeforeUpdate: func(actual, desired *apiv1beta2.OCIRepository) {
+			actual.Labels = desired.Labels
+			actual.Spec = desired.Spec
+		},
+		ReflectChildStatusOnParent: func(ctx context.Context, parent *v1alpha1.MonoRepository, child *apiv1beta2.OCIRepository, err error) {
+			if reflectChildError(ctx, parent, "OCIRepository", err) || child == nil {
+				return
+			}
+			reflectSource(ctx, o, parent, "OCIRepository", client.ObjectKeyFromObject(child), child, child.Status.Artifact)
+		},
+		Sanitize: func(child *apiv1beta2.OCIRepository) any {
+			return child.Spec
+		},
+	}
+}
@@ -9,25 +9,45 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
+var serveOnce sync.Once
+
+// ServeDir serves path as a tarball at http://localhost:8080/file.tar.gz, the
+// server is shared by every test in the package.
 func ServeDir(t *testing.T, path string) {
+	serveOnce.Do(func() {
+		serveDir(t, path)
+	})
+}
+
+func serveDir(t *testing.T, path string) {
 	http.HandleFunc("/file.tar.gz", func(writer http.ResponseWriter, request *http.Request) {
 		gw := gzip.NewWriter(writer)
 		defer gw.Close()
 		tw := tar.NewWriter(gw)
 		defer tw.Close()
 
-		_ = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		_ = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
 			th, err := tar.FileInfoHeader(info, "")
 			if err != nil {
 				return err
 			}
-			fh, err := os.Open(path)
+			// store paths relative to the served directory, as the source-controller does
+			rel, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+			th.Name = filepath.ToSlash(rel)
+			fh, err := os.Open(file)
 			if err != nil {
 				return err
 			}
@@ -44,12 +64,11 @@ func ServeDir(t *testing.T, path string) {
 
 	listener, err := net.Listen("tcp", "localhost:8080")
 	if err != nil {
-		t.Errorf("unable to bind %v", err)
+		t.Fatalf("unable to bind %v", err)
 	}
 
-	// #nosec
-	err = http.Serve(listener, nil)
-	if err != nil {
-		t.Errorf("unable to serve %v", err)
-	}
+	go func() {
+		// #nosec
+		_ = http.Serve(listener, nil)
+	}()
 }
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+)
+
+// snapshot gives access to the content of a source artifact.
+type snapshot interface {
+	// Filter hashes the files selected by each filter and prepares an archive
+	// of them, reading the artifact once.
+	Filter(filters ...*filter) error
+
+	// Files returns the sorted paths of every file in the artifact.
+	Files() ([]string, error)
+
+	// ReadFile returns the content of a single file, false if it is not present.
+	ReadFile(name string) ([]byte, bool, error)
+
+	// Close releases any resources held by the snapshot.
+	Close()
+}
+
+// filter selects the files of a snapshot that make up a filtered artifact.
+type filter struct {
+	// dir is the directory of the storage the filtered artifact is stored in.
+	dir string
+
+	// match returns true for the files selected by the filter, it is called once
+	// for every file in the artifact.
+	match func(name string) bool
+
+	// manifest holds the selected files once the snapshot has been filtered.
+	manifest util.Manifest
+
+	store   func(path string) (string, int64, error)
+	discard func()
+}
+
+// Store writes the archive of the selected files to path, which must be within
+// the directory of the filter. It returns the digest and size of the archive.
+func (f *filter) Store(path string) (string, int64, error) {
+	return f.store(path)
+}
+
+// Close releases the archive when it has not been stored.
+func (f *filter) Close() {
+	if f.discard != nil {
+		f.discard()
+	}
+}
+
+// openSnapshot returns a snapshot of the artifact. When the cache is enabled the
+// artifact is extracted once and shared with other MonoRepositories, otherwise
+// the artifact is streamed from the source without being extracted. Any
+// download is recorded in m.
+func openSnapshot(ctx context.Context, o Options, artifact *apiv1.Artifact, m *sourceMetrics) (snapshot, error) {
+	if !o.Cache.Enabled() {
+		return &streamSnapshot{url: artifact.URL, storage: o.Storage, tempDir: o.TempDir, metrics: m}, nil
+	}
+
+	dir, release, err := o.Cache.Get(cacheKey(artifact), func(dir string) error {
+		return fetchArtifact(ctx, artifact, dir, o.TempDir, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dirSnapshot{dir: dir, storage: o.Storage, release: release}, nil
+}
+
+// dirSnapshot is an artifact that has been extracted to a directory, the files
+// are only archived when the filtered artifact is stored.
+type dirSnapshot struct {
+	dir     string
+	storage *storage.Storage
+	release func()
+}
+
+func (d *dirSnapshot) Filter(filters ...*filter) error {
+	for _, f := range filters {
+		manifest, err := util.HashDir(d.dir, f.match)
+		if err != nil {
+			return err
+		}
+		f.manifest = manifest
+		f.store = func(path string) (string, int64, error) {
+			return d.storage.ArchiveFiles(path, d.dir, manifest.Files())
+		}
+	}
+	return nil
+}
+
+func (d *dirSnapshot) Files() ([]string, error) {
+	return util.ListFiles(d.dir)
+}
+
+func (d *dirSnapshot) ReadFile(name string) ([]byte, bool, error) {
+	p, err := util.SanitizeArchivePath(d.dir, name)
+	if err != nil {
+		return nil, false, err
+	}
+	content, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	return content, err == nil, err
+}
+
+func (d *dirSnapshot) Close() {
+	d.release()
+}
+
+// streamSnapshot is an artifact that is read directly from the source without
+// being extracted. Filtering hashes the selected files while writing them to a
+// temporary archive in the storage, so the artifact is read once. Reading a file
+// ahead of filtering, e.g. the includeFrom file, also keeps a copy of the
+// compressed artifact in tempDir, so that it is not downloaded again.
+type streamSnapshot struct {
+	url     string
+	storage *storage.Storage
+	tempDir string
+	metrics *sourceMetrics
+
+	// spool is the local copy of the artifact, once it has been downloaded.
+	spool string
+}
+
+// open returns the artifact, from the local copy when there is one.
+func (s *streamSnapshot) open() (io.ReadCloser, error) {
+	if s.spool != "" {
+		return os.Open(s.spool)
+	}
+
+	start := time.Now()
+	r, err := util.OpenURL(s.url)
+	if err != nil {
+		return nil, &stepError{reason: v1alpha1.DownloadFailedReason, err: err}
+	}
+	return &meteredReader{ReadCloser: r, metrics: s.metrics, start: start}, nil
+}
+
+func (s *streamSnapshot) Filter(filters ...*filter) error {
+	matches := make([]func(name string) bool, len(filters))
+	writers := make([]*tar.Writer, len(filters))
+	archives := make([]*storage.ArchiveWriter, 0, len(filters))
+	discard := func() {
+		for _, w := range archives {
+			w.Discard()
+		}
+	}
+
+	for i, f := range filters {
+		w, err := s.storage.NewArchiveWriter(f.dir)
+		if err != nil {
+			discard()
+			return err
+		}
+		archives = append(archives, w)
+		matches[i] = f.match
+		writers[i] = w.Writer
+	}
+
+	r, err := s.open()
+	if err != nil {
+		discard()
+		return err
+	}
+	defer r.Close()
+
+	manifests, err := util.FilterTarGz(r, matches, writers, storage.Header)
+	if err != nil {
+		discard()
+		return err
+	}
+	for i, f := range filters {
+		f.manifest = manifests[i]
+		f.store = archives[i].Commit
+		f.discard = archives[i].Discard
+	}
+	return nil
+}
+
+func (s *streamSnapshot) Files() ([]string, error) {
+	r, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return util.ListTarGz(r)
+}
+
+// ReadFile reads a single file while keeping a local copy of the artifact, the
+// rest of the artifact is read into the copy once the file has been found.
+func (s *streamSnapshot) ReadFile(name string) ([]byte, bool, error) {
+	if s.spool != "" {
+		r, err := s.open()
+		if err != nil {
+			return nil, false, err
+		}
+		defer r.Close()
+		return util.ReadTarGzFile(r, name)
+	}
+
+	spool, err := os.CreateTemp(s.tempDir, "artifact-*.tar.gz")
+	if err != nil {
+		return nil, false, err
+	}
+	defer spool.Close()
+
+	r, err := s.open()
+	if err != nil {
+		_ = os.Remove(spool.Name())
+		return nil, false, err
+	}
+	defer r.Close()
+
+	tee := io.TeeReader(r, spool)
+	content, found, err := util.ReadTarGzFile(tee, name)
+	if err == nil {
+		_, err = io.Copy(io.Discard, tee)
+	}
+	if err != nil {
+		_ = os.Remove(spool.Name())
+		return nil, false, err
+	}
+	s.spool = spool.Name()
+	return content, found, nil
+}
+
+func (s *streamSnapshot) Close() {
+	if s.spool != "" {
+		_ = os.Remove(s.spool)
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// serveArtifact serves a tarball of the testdata directory, counting the number
+// of times it is downloaded.
+func serveArtifact(t *testing.T) (string, *int32) {
+	files, err := util.ListFiles("testdata")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join("testdata", file))
+		assert.NoError(t, err)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+		}))
+		_, err = tw.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/artifact.tar.gz", &requests
+}
+
+// archivedFiles returns the names of the entries of a stored tarball.
+func archivedFiles(t *testing.T, s *storage.Storage, path string) []string {
+	f, err := os.Open(s.LocalPath(path))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	files, err := util.ListTarGz(f)
+	assert.NoError(t, err)
+	return files
+}
+
+func TestStreamSnapshotFilter(t *testing.T) {
+	url, requests := serveArtifact(t)
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	snap := &streamSnapshot{url: url, storage: s, tempDir: t.TempDir()}
+	defer snap.Close()
+
+	dir := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "")
+	stored := &filter{dir: dir, match: util.NewMatcher("/dir01")}
+	discarded := &filter{dir: dir, match: util.NewMatcher("/dir02")}
+	assert.NoError(t, snap.Filter(stored, discarded))
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	assert.Equal(t, []string{"dir01/test.txt"}, stored.manifest.Files())
+	assert.Equal(t, []string{"dir02/.monorepo-include", "dir02/test.txt"}, discarded.manifest.Files())
+
+	path := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "stored.tar.gz")
+	digest, size, err := stored.Store(path)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, digest)
+	assert.NotZero(t, size)
+	assert.Equal(t, []string{"dir01/test.txt"}, archivedFiles(t, s, path))
+
+	// only the stored archive remains once the filters are closed
+	stored.Close()
+	discarded.Close()
+	entries, err := os.ReadDir(s.LocalPath(dir))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "stored.tar.gz", entries[0].Name())
+}
+
+func TestStreamSnapshotReadFile(t *testing.T) {
+	url, requests := serveArtifact(t)
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	tempDir := t.TempDir()
+	snap := &streamSnapshot{url: url, storage: s, tempDir: tempDir}
+
+	content, found, err := snap.ReadFile("dir02/.monorepo-include")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "/dir02\n!*.md\n", string(content))
+
+	// the artifact read while looking for the file is used by the filter
+	f := &filter{dir: s.ArtifactPath("MonoRepository", "dev", "mono-repository", ""), match: util.NewMatcher(string(content))}
+	assert.NoError(t, snap.Filter(f))
+	defer f.Close()
+	assert.Equal(t, []string{"dir02/.monorepo-include", "dir02/test.txt"}, f.manifest.Files())
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// the local copy of the artifact is removed once the snapshot is closed
+	snap.Close()
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestReflectArtifactDownloadsOnce(t *testing.T) {
+	url, requests := serveArtifact(t)
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	ctx := reconcilers.StashConfig(context.Background(), reconcilers.Config{Recorder: record.NewFakeRecorder(100)})
+	o := Options{Storage: s, TempDir: t.TempDir()}
+	artifact := &apiv1.Artifact{URL: url, Revision: "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7"}
+
+	for name, spec := range map[string]v1alpha1.MonoRepositorySpec{
+		"include":     {Include: "/dir01"},
+		"includeFrom": {IncludeFrom: "dir02/.monorepo-include"},
+		"components": {
+			Include: "/dir01",
+			Components: map[string]v1alpha1.Component{
+				"one": {Include: "/dir01"},
+				"two": {Include: "/dir02"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parent := &v1alpha1.MonoRepository{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: name},
+				Spec:       spec,
+			}
+
+			for i := 1; i <= 2; i++ {
+				atomic.StoreInt32(requests, 0)
+				ReflectArtifact(ctx, o, parent, artifact)
+				assert.Equal(t, int32(1), atomic.LoadInt32(requests), "reconcile %d", i)
+				if assert.NotNil(t, parent.Status.Artifact) {
+					assert.True(t, s.Exists(parent.Status.Artifact.Path))
+				}
+				for component := range spec.Components {
+					if assert.NotNil(t, parent.Status.Components[component].Artifact) {
+						assert.True(t, s.Exists(parent.Status.Components[component].Artifact.Path))
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	apiv1 "github.com/fluxcd/source-controller/api/v1"
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// source is implemented by the Flux sources that can be referenced.
+type source interface {
+	client.Object
+	meta.ObjectWithConditions
+	GetArtifact() *apiv1.Artifact
+}
+
+// newSource returns an empty source of the referenced kind.
+func newSource(kind string) (source, error) {
+	switch kind {
+	case "GitRepository":
+		return &apiv1beta2.GitRepository{}, nil
+	case "OCIRepository":
+		return &apiv1beta2.OCIRepository{}, nil
+	case "Bucket":
+		return &apiv1beta2.Bucket{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source kind %q", kind)
+	}
+}
+
+// sourceStatus is the status of a resource that references a source.
+type sourceStatus interface {
+	MarkFailed(ctx context.Context, err error)
+	MarkSourceNotFound(ctx context.Context, source string)
+	MarkSourceOutOfScope(ctx context.Context, source string)
+	MarkSourceAccessDenied(ctx context.Context, source string)
+}
+
+// getSource tracks and returns the source referenced from namespace, so that the
+// referencing resource is reconciled whenever the source changes. A source that
+// cannot be returned is reported on the status and the source is nil.
+func getSource(ctx context.Context, c reconcilers.Config, o Options, namespace string, ref v1alpha1.SourceReference, status sourceStatus) (source, types.NamespacedName, error) {
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if key.Namespace == "" {
+		key.Namespace = namespace
+	}
+
+	src, err := newSource(ref.Kind)
+	if err != nil {
+		status.MarkFailed(ctx, err)
+		return nil, key, nil
+	}
+
+	if key.Namespace != namespace && !o.AllowCrossNamespaceRefs {
+		// the artifact would be republished to anyone able to read the referencing resource
+		status.MarkSourceAccessDenied(ctx, fmt.Sprintf("%s %s", ref.Kind, key))
+		return nil, key, nil
+	}
+
+	if !o.watches(key.Namespace) {
+		// the cache cannot read the source, and would never notify us of changes
+		status.MarkSourceOutOfScope(ctx, fmt.Sprintf("%s %s", ref.Kind, key))
+		return nil, key, nil
+	}
+
+	if err := c.TrackAndGet(ctx, key, src); err != nil {
+		if apierrs.IsNotFound(err) {
+			// the source is tracked, we will be reconciled again once it is created
+			status.MarkSourceNotFound(ctx, fmt.Sprintf("%s %s", ref.Kind, key))
+			return nil, key, nil
+		}
+		return nil, key, err
+	}
+	return src, key, nil
+}
+
+// NewSourceRefReconciler reflects the artifact of an existing source referenced by
+// the MonoRepository. The source is tracked so that the MonoRepository is
+// reconciled whenever the source changes.
+func NewSourceRefReconciler(c reconcilers.Config, o Options) reconcilers.SubReconciler[*v1alpha1.MonoRepository] {
+	return &reconcilers.SyncReconciler[*v1alpha1.MonoRepository]{
+		Name: "SourceRef",
+		Setup: func(ctx context.Context, mgr ctrl.Manager, bldr *builder.Builder) error {
+			bldr.Watches(&apiv1beta2.GitRepository{}, reconcilers.EnqueueTracked(ctx))
+			bldr.Watches(&apiv1beta2.OCIRepository{}, reconcilers.EnqueueTracked(ctx))
+			bldr.Watches(&apiv1beta2.Bucket{}, reconcilers.EnqueueTracked(ctx))
+			return nil
+		},
+		Sync: func(ctx context.Context, parent *v1alpha1.MonoRepository) error {
+			ref := parent.Spec.SourceRef
+			if ref == nil {
+				return nil
+			}
+
+			src, key, err := getSource(ctx, c, o, parent.Namespace, *ref, &parent.Status)
+			if err != nil || src == nil {
+				return err
+			}
+
+			reflectSource(ctx, o, parent, ref.Kind, key, src, src.GetArtifact())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package defaulting
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+//+kubebuilder:webhook:path=/default-source-garethjevans-org-monorepository,mutating=true,failurePolicy=fail,sideEffects=None,groups=source.garethjevans.org,resources=monorepositories,verbs=create;update,versions={v1alpha1},matchPolicy=equivalent,name=default.monorepository.source.garethjevans.org,admissionReviewVersions={v1,v1beta1}
+
+const (
+	// DefaultedAnnotation lists the fields of the spec that have been defaulted.
+	DefaultedAnnotation = "source.garethjevans.org/defaulted"
+
+	// DefaultInclude selects every file in the artifact.
+	DefaultInclude = "/*"
+
+	// DefaultBranch is the branch of a GitRepository without a ref.
+	DefaultBranch = "main"
+
+	// DefaultTag is the tag of an OCIRepository without a ref.
+	DefaultTag = "latest"
+)
+
+var (
+	// DefaultInterval is the interval at which the source is checked for updates.
+	DefaultInterval = metav1.Duration{Duration: time.Minute}
+
+	// DefaultTimeout is the timeout of the operations of the source.
+	DefaultTimeout = metav1.Duration{Duration: 60 * time.Second}
+)
+
+func RegisterDefaultingWebhooks(mgr manager.Manager) {
+	c := reconcilers.NewConfig(mgr, nil, 0)
+	mgr.GetWebhookServer().Register("/default-source-garethjevans-org-monorepository", MonoRepositoryDefaultingWebhook(c).Build())
+}
+
+// MonoRepositoryDefaultingWebhook fills in the fields users commonly forget and
+// records each defaulted field in the DefaultedAnnotation.
+func MonoRepositoryDefaultingWebhook(c reconcilers.Config) *reconcilers.AdmissionWebhookAdapter[*v1alpha1.MonoRepository] {
+	return &reconcilers.AdmissionWebhookAdapter[*v1alpha1.MonoRepository]{
+		Name: "MonoRepositoryDefaultingWebhook",
+		Reconciler: &reconcilers.SyncReconciler[*v1alpha1.MonoRepository]{
+			Setup: func(ctx context.Context, mgr manager.Manager, bldr *builder.Builder) error {
+				return nil
+			},
+			Sync: func(ctx context.Context, resource *v1alpha1.MonoRepository) error {
+				req := reconcilers.RetrieveAdmissionRequest(ctx)
+				if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+					return nil
+				}
+
+				Apply(resource)
+				return nil
+			},
+		},
+		Config: c,
+	}
+}
+
+// Apply sets the defaults of the MonoRepository and records the defaulted fields
+// in the DefaultedAnnotation, as the webhook does.
+func Apply(resource *v1alpha1.MonoRepository) {
+	if defaulted := Default(resource); len(defaulted) > 0 {
+		annotate(resource, defaulted)
+	}
+}
+
+// Default sets the defaults of the MonoRepository, returning the path of each
+// field that has been defaulted. The ignore field of a source is left alone, as
+// source-controller applies it on top of its own exclusions of version control
+// files, images, archives and CI configuration, which no value can turn off.
+func Default(resource *v1alpha1.MonoRepository) []string {
+	var defaulted []string
+	set := func(field string) {
+		defaulted = append(defaulted, field)
+	}
+
+	spec := &resource.Spec
+	if spec.Include == "" && spec.IncludeFrom == "" && len(spec.Includes) == 0 {
+		spec.Include = DefaultInclude
+		set("spec.include")
+	}
+
+	if git := spec.GitRepository; git != nil {
+		if git.Interval.Duration == 0 {
+			git.Interval = DefaultInterval
+			set("spec.gitRepository.interval")
+		}
+		if git.Timeout == nil {
+			git.Timeout = DefaultTimeout.DeepCopy()
+			set("spec.gitRepository.timeout")
+		}
+		if git.Reference == nil {
+			git.Reference = &apiv1beta2.GitRepositoryRef{Branch: DefaultBranch}
+			set("spec.gitRepository.ref")
+		}
+	}
+
+	if oci := spec.OCIRepository; oci != nil {
+		if oci.Interval.Duration == 0 {
+			oci.Interval = DefaultInterval
+			set("spec.ociRepository.interval")
+		}
+		if oci.Timeout == nil {
+			oci.Timeout = DefaultTimeout.DeepCopy()
+			set("spec.ociRepository.timeout")
+		}
+		if oci.Reference == nil {
+			oci.Reference = &apiv1beta2.OCIRepositoryRef{Tag: DefaultTag}
+			set("spec.ociRepository.ref")
+		}
+	}
+
+	if bucket := spec.Bucket; bucket != nil {
+		if bucket.Interval.Duration == 0 {
+			bucket.Interval = DefaultInterval
+			set("spec.bucket.interval")
+		}
+		if bucket.Timeout == nil {
+			bucket.Timeout = DefaultTimeout.DeepCopy()
+			set("spec.bucket.timeout")
+		}
+	}
+
+	return defaulted
+}
+
+// annotate merges the defaulted fields into the DefaultedAnnotation, keeping the
+// fields recorded by earlier requests.
+func annotate(resource *v1alpha1.MonoRepository, defaulted []string) {
+	fields := map[string]bool{}
+	for _, field := range strings.Split(resource.Annotations[DefaultedAnnotation], ",") {
+		if field != "" {
+			fields[field] = true
+		}
+	}
+	for _, field := range defaulted {
+		fields[field] = true
+	}
+
+	list := make([]string, 0, len(fields))
+	for field := range fields {
+		list = append(list, field)
+	}
+	sort.Strings(list)
+
+	if resource.Annotations == nil {
+		resource.Annotations = map[string]string{}
+	}
+	resource.Annotations[DefaultedAnnotation] = strings.Join(list, ",")
+}
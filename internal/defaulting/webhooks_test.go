@@ -0,0 +1,188 @@
+package defaulting_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/defaulting"
+	"github.com/garethjevans/monorepository-controller/internal/tests/resources"
+
+	v1 "dies.dev/apis/meta/v1"
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDefault(t *testing.T) {
+	resource := &v1alpha1.MonoRepository{
+		Spec: v1alpha1.MonoRepositorySpec{
+			GitRepository: &apiv1beta2.GitRepositorySpec{
+				URL: "https://github.com/org/repo",
+			},
+		},
+	}
+
+	defaulted := defaulting.Default(resource)
+	assert.Equal(t, []string{
+		"spec.include",
+		"spec.gitRepository.interval",
+		"spec.gitRepository.timeout",
+		"spec.gitRepository.ref",
+	}, defaulted)
+	assert.Equal(t, &v1alpha1.MonoRepository{
+		Spec: v1alpha1.MonoRepositorySpec{
+			GitRepository: &apiv1beta2.GitRepositorySpec{
+				URL:       "https://github.com/org/repo",
+				Interval:  metav1.Duration{Duration: time.Minute},
+				Timeout:   &metav1.Duration{Duration: time.Minute},
+				Reference: &apiv1beta2.GitRepositoryRef{Branch: "main"},
+			},
+			Include: "/*",
+		},
+	}, resource)
+
+	// a fully specified resource is left alone
+	assert.Empty(t, defaulting.Default(resource))
+
+	resource = &v1alpha1.MonoRepository{
+		Spec: v1alpha1.MonoRepositorySpec{
+			OCIRepository: &apiv1beta2.OCIRepositorySpec{
+				URL:      "oci://ghcr.io/org/repo",
+				Interval: metav1.Duration{Duration: time.Hour},
+			},
+			IncludeFrom: "services/foo/.monorepo-include",
+		},
+	}
+	assert.Equal(t, []string{
+		"spec.ociRepository.timeout",
+		"spec.ociRepository.ref",
+	}, defaulting.Default(resource))
+	assert.Equal(t, &apiv1beta2.OCIRepositoryRef{Tag: "latest"}, resource.Spec.OCIRepository.Reference)
+	assert.Empty(t, resource.Spec.Include)
+}
+
+func TestMonoRepositoryDefaultingWebhook(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	base := resources.MonoRepositoryBlank.
+		APIVersion(v1alpha1.GroupVersion.String()).
+		Kind("MonoRepository").
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("mono-repository")
+			d.Namespace("dev")
+		})
+
+	request := func(op admissionv1.Operation, obj *v1alpha1.MonoRepository) *admission.Request {
+		raw, err := json.Marshal(obj)
+		utilruntime.Must(err)
+		return &admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "request-uid",
+				Operation: op,
+				Namespace: obj.Namespace,
+				Name:      obj.Name,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+	}
+
+	wts := rtesting.AdmissionWebhookTests{
+		"records the defaulted fields": {
+			Request: request(admissionv1.Create, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Bucket(&apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio:9000",
+						Timeout:    &metav1.Duration{Duration: time.Minute},
+					})
+					d.Includes("/dir01")
+				}).DieReleasePtr()),
+			ExpectedResponse: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					UID:     "request-uid",
+					Allowed: true,
+				},
+				Patches: []jsonpatch.Operation{
+					{
+						Operation: "add",
+						Path:      "/metadata/annotations",
+						Value: map[string]interface{}{
+							defaulting.DefaultedAnnotation: "spec.bucket.interval",
+						},
+					},
+					{
+						Operation: "replace",
+						Path:      "/spec/bucket/interval",
+						Value:     "1m0s",
+					},
+				},
+			},
+		},
+		"keeps fields defaulted by earlier requests": {
+			Request: request(admissionv1.Update, base.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.AddAnnotation(defaulting.DefaultedAnnotation, "spec.include")
+				}).
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Bucket(&apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio:9000",
+						Interval:   metav1.Duration{Duration: time.Hour},
+					})
+					d.Include("/*")
+				}).DieReleasePtr()),
+			ExpectedResponse: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					UID:     "request-uid",
+					Allowed: true,
+				},
+				Patches: []jsonpatch.Operation{
+					{
+						Operation: "replace",
+						Path:      "/metadata/annotations/source.garethjevans.org~1defaulted",
+						Value:     "spec.bucket.timeout,spec.include",
+					},
+					{
+						Operation: "add",
+						Path:      "/spec/bucket/timeout",
+						Value:     "1m0s",
+					},
+				},
+			},
+		},
+		"leaves a complete resource alone": {
+			Request: request(admissionv1.Create, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Bucket(&apiv1beta2.BucketSpec{
+						BucketName: "snapshots",
+						Endpoint:   "minio:9000",
+						Interval:   metav1.Duration{Duration: time.Hour},
+						Timeout:    &metav1.Duration{Duration: time.Minute},
+					})
+					d.Include("/*")
+				}).DieReleasePtr()),
+			ExpectedResponse: admission.Response{
+				AdmissionResponse: admissionv1.AdmissionResponse{
+					UID:     "request-uid",
+					Allowed: true,
+				},
+			},
+		},
+	}
+
+	wts.Run(t, scheme, func(t *testing.T, wtc *rtesting.AdmissionWebhookTestCase, c reconcilers.Config) *admission.Webhook {
+		return defaulting.MonoRepositoryDefaultingWebhook(c).Build()
+	})
+}
@@ -2,16 +2,34 @@ package integrity
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/util"
 
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/vmware-labs/reconciler-runtime/reconcilers"
 	admissionv1 "k8s.io/api/admission/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 //+kubebuilder:webhook:path=/integrity-source-garethjevans-org-monorepository,mutating=false,failurePolicy=fail,sideEffects=None,groups=source.garethjevans.org,resources=monorepositories,verbs=create;update;delete,versions={v1alpha1},matchPolicy=equivalent,name=integrity.monorepository.source.garethjevans.org,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=kustomize.toolkit.fluxcd.io,resources=kustomizations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=carto.run,resources=workloads,verbs=get;list;watch
+
+// ForceDeleteAnnotation allows a MonoRepository to be deleted while it is still
+// referenced when set to "true".
+const ForceDeleteAnnotation = "source.garethjevans.org/force-delete"
 
 func RegisterReferentialIntegrityWebhooks(mgr manager.Manager) {
 	c := reconcilers.NewConfig(mgr, nil, 0)
@@ -27,20 +45,208 @@ func MonoRepositoryReferentialIntegrityWebhook(c reconcilers.Config) *reconciler
 				return nil
 			},
 			Sync: func(ctx context.Context, resource *v1alpha1.MonoRepository) error {
-				//c := reconcilers.RetrieveConfigOrDie(ctx)
 				req := reconcilers.RetrieveAdmissionRequest(ctx)
-				//resp := reconcilers.RetrieveAdmissionResponse(ctx)
+				resp := reconcilers.RetrieveAdmissionResponse(ctx)
 
+				var err error
 				switch req.Operation {
 				case admissionv1.Create, admissionv1.Update:
-					// FIXME
+					err = validateInclude(resource)
+					if err == nil {
+						err = validateOwnership(ctx, c, resource)
+					}
 				case admissionv1.Delete:
-					// FIXME
+					if resource.Annotations[ForceDeleteAnnotation] != "true" {
+						err = validateUnreferenced(ctx, c, resource)
+					}
 				}
 
-				return nil
+				var denied *deniedError
+				if errors.As(err, &denied) {
+					resp.Allowed = false
+					resp.Result = admission.Denied(denied.Error()).Result
+					return nil
+				}
+				return err
 			},
 		},
 		Config: c,
 	}
 }
+
+// deniedError is returned when the request is rejected, rather than failing.
+type deniedError struct {
+	msg string
+}
+
+func (e *deniedError) Error() string {
+	return e.msg
+}
+
+func deny(format string, a ...any) error {
+	return &deniedError{msg: fmt.Sprintf(format, a...)}
+}
+
+// validateInclude rejects include rules that do not parse, or that can never
+// select a file because every rule is a negation.
+func validateInclude(resource *v1alpha1.MonoRepository) error {
+	spec := resource.Spec
+	if err := validateRules("spec", spec.Include, spec.Includes, spec.Exclude, spec.IncludeFrom == ""); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(spec.Components))
+	for name := range spec.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		component := spec.Components[name]
+		if err := validateRules(fmt.Sprintf("spec.components[%s]", name), component.Include, component.Includes, component.Exclude, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRules(field, include string, includes []string, exclude []string, requireSelection bool) error {
+	selects, err := util.ValidateInclude(include)
+	if err != nil {
+		return deny("%s.include: %s", field, err)
+	}
+	for _, pattern := range append(append([]string{}, includes...), exclude...) {
+		if _, err := util.ValidateInclude(pattern); err != nil {
+			return deny("%s: %s", field, err)
+		}
+	}
+	if requireSelection && !selects && len(includes) == 0 && include != "" {
+		return deny("%s.include only contains negations and will never select a file", field)
+	}
+	return nil
+}
+
+// validateOwnership rejects a MonoRepository that would take over an existing
+// source, with the same name, that it does not already manage. The source is
+// read from the API server, the cache of the manager may be limited to the
+// watched namespaces.
+func validateOwnership(ctx context.Context, c reconcilers.Config, resource *v1alpha1.MonoRepository) error {
+	var child client.Object
+	switch {
+	case resource.Spec.GitRepository != nil:
+		child = &apiv1beta2.GitRepository{}
+	case resource.Spec.OCIRepository != nil:
+		child = &apiv1beta2.OCIRepository{}
+	case resource.Spec.Bucket != nil:
+		child = &apiv1beta2.Bucket{}
+	default:
+		return nil
+	}
+
+	key := types.NamespacedName{Namespace: resource.Namespace, Name: resource.Name}
+	if err := c.APIReader.Get(ctx, key, child); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, ref := range child.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+		if gv.Group == v1alpha1.GroupVersion.Group && ref.Kind == "MonoRepository" && ref.Name == resource.Name {
+			return nil
+		}
+	}
+
+	kind, err := c.GroupVersionKindFor(child)
+	if err != nil {
+		return err
+	}
+	return deny("%s %s already exists and is not managed by this MonoRepository", kind.Kind, key)
+}
+
+// referrer is a kind of resource that may reference a MonoRepository.
+type referrer struct {
+	gvk schema.GroupVersionKind
+	// allNamespaces is true when resources in any namespace may reference the MonoRepository
+	allNamespaces bool
+	// references returns true when obj references the MonoRepository
+	references func(obj *unstructured.Unstructured, resource *v1alpha1.MonoRepository) bool
+}
+
+var referrers = []referrer{
+	{
+		gvk:           schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"},
+		allNamespaces: true,
+		references: func(obj *unstructured.Unstructured, resource *v1alpha1.MonoRepository) bool {
+			ref, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "sourceRef")
+			return isReference(ref, obj.GetNamespace(), resource)
+		},
+	},
+	{
+		gvk: schema.GroupVersionKind{Group: "carto.run", Version: "v1alpha1", Kind: "Workload"},
+		references: func(obj *unstructured.Unstructured, resource *v1alpha1.MonoRepository) bool {
+			resources, _, _ := unstructured.NestedSlice(obj.Object, "status", "resources")
+			for _, r := range resources {
+				m, ok := r.(map[string]any)
+				if !ok {
+					continue
+				}
+				ref, _, _ := unstructured.NestedStringMap(m, "stampedRef")
+				if isReference(ref, obj.GetNamespace(), resource) {
+					return true
+				}
+			}
+			return false
+		},
+	},
+}
+
+// isReference returns true when the kind, name and namespace of ref match the
+// MonoRepository, the namespace defaults to that of the referencing resource.
+func isReference(ref map[string]string, namespace string, resource *v1alpha1.MonoRepository) bool {
+	if ns := ref["namespace"]; ns != "" {
+		namespace = ns
+	}
+	return ref["kind"] == "MonoRepository" && ref["name"] == resource.Name && namespace == resource.Namespace
+}
+
+// validateUnreferenced rejects the deletion of a MonoRepository that is still
+// referenced. Kinds that are not installed in the cluster are ignored, as are
+// referencing resources that are themselves being deleted. Referencing
+// resources are listed from the API server, as they may be in namespaces that
+// are not watched by the manager.
+func validateUnreferenced(ctx context.Context, c reconcilers.Config, resource *v1alpha1.MonoRepository) error {
+	var found []string
+	for _, r := range referrers {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(r.gvk.GroupVersion().WithKind(r.gvk.Kind + "List"))
+
+		var opts []client.ListOption
+		if !r.allNamespaces {
+			opts = append(opts, client.InNamespace(resource.Namespace))
+		}
+		if err := c.APIReader.List(ctx, list, opts...); err != nil {
+			if apimeta.IsNoMatchError(err) || apierrs.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() != nil || !r.references(obj, resource) {
+				continue
+			}
+			found = append(found, fmt.Sprintf("%s %s/%s", r.gvk.Kind, obj.GetNamespace(), obj.GetName()))
+		}
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+	sort.Strings(found)
+	return deny("MonoRepository %s/%s is referenced by %v, set the %s annotation to \"true\" to delete it anyway", resource.Namespace, resource.Name, found, ForceDeleteAnnotation)
+}
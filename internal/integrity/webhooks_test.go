@@ -0,0 +1,202 @@
+package integrity_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	"github.com/garethjevans/monorepository-controller/internal/integrity"
+	"github.com/garethjevans/monorepository-controller/internal/tests/resources"
+
+	v1 "dies.dev/apis/meta/v1"
+	apiv1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/vmware-labs/reconciler-runtime/reconcilers"
+	rtesting "github.com/vmware-labs/reconciler-runtime/testing"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestMonoRepositoryReferentialIntegrityWebhook(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta2.AddToScheme(scheme))
+
+	// Flux Kustomizations are only known as unstructured resources, Cartographer
+	// Workloads are not installed at all
+	kustomizationGVK := schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}
+	scheme.AddKnownTypeWithName(kustomizationGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(kustomizationGVK.GroupVersion().WithKind("KustomizationList"), &unstructured.UnstructuredList{})
+
+	base := resources.MonoRepositoryBlank.
+		APIVersion(v1alpha1.GroupVersion.String()).
+		Kind("MonoRepository").
+		MetadataDie(func(d *v1.ObjectMetaDie) {
+			d.Name("mono-repository")
+			d.Namespace("dev")
+		}).
+		SpecDie(func(d *resources.MonoRepositorySpecDie) {
+			d.GitRepository(&apiv1beta2.GitRepositorySpec{
+				URL: "https://github.com/org/repo",
+			})
+			d.Include("/dir01")
+		})
+
+	request := func(op admissionv1.Operation, obj *v1alpha1.MonoRepository) *admission.Request {
+		raw, err := json.Marshal(obj)
+		utilruntime.Must(err)
+		req := &admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "request-uid",
+				Operation: op,
+				Namespace: obj.Namespace,
+				Name:      obj.Name,
+			},
+		}
+		if op == admissionv1.Delete {
+			req.OldObject = runtime.RawExtension{Raw: raw}
+		} else {
+			req.Object = runtime.RawExtension{Raw: raw}
+		}
+		return req
+	}
+
+	allowed := admission.Response{
+		AdmissionResponse: admissionv1.AdmissionResponse{
+			UID:     "request-uid",
+			Allowed: true,
+		},
+	}
+
+	denied := func(msg string) admission.Response {
+		resp := admission.Denied(msg)
+		resp.UID = "request-uid"
+		return resp
+	}
+
+	kustomization := func(name string, sourceRef map[string]any) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(kustomizationGVK)
+		u.SetNamespace("dev")
+		u.SetName(name)
+		utilruntime.Must(unstructured.SetNestedMap(u.Object, sourceRef, "spec", "sourceRef"))
+		return u
+	}
+
+	wts := rtesting.AdmissionWebhookTests{
+		"allows a valid MonoRepository": {
+			Request:          request(admissionv1.Create, base.DieReleasePtr()),
+			ExpectedResponse: allowed,
+		},
+		"rejects include rules that do not parse": {
+			Request: request(admissionv1.Create, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Include("/dir[01")
+				}).DieReleasePtr()),
+			ExpectedResponse: denied(`spec.include: invalid include rule "/dir[01": syntax error in pattern`),
+		},
+		"rejects include rules that only contain negations": {
+			Request: request(admissionv1.Update, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Include("!*.md\n!.*")
+				}).DieReleasePtr()),
+			ExpectedResponse: denied("spec.include only contains negations and will never select a file"),
+		},
+		"allows negations when the includes list selects files": {
+			Request: request(admissionv1.Update, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Include("!*.md")
+					d.Includes("/dir01")
+				}).DieReleasePtr()),
+			ExpectedResponse: allowed,
+		},
+		"rejects component rules that only contain negations": {
+			Request: request(admissionv1.Create, base.
+				SpecDie(func(d *resources.MonoRepositorySpecDie) {
+					d.Components(map[string]v1alpha1.Component{
+						"docs": {Include: "!*.go"},
+					})
+				}).DieReleasePtr()),
+			ExpectedResponse: denied("spec.components[docs].include only contains negations and will never select a file"),
+		},
+		"rejects taking over an unmanaged GitRepository": {
+			Request: request(admissionv1.Create, base.DieReleasePtr()),
+			// sources are read from the API server, the manager cache may be
+			// limited to the watched namespaces
+			APIGivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+					},
+				},
+			},
+			ExpectedResponse: denied("GitRepository dev/mono-repository already exists and is not managed by this MonoRepository"),
+		},
+		"allows updating a MonoRepository that manages its GitRepository": {
+			Request: request(admissionv1.Update, base.DieReleasePtr()),
+			APIGivenObjects: []client.Object{
+				&apiv1beta2.GitRepository{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mono-repository",
+						Namespace: "dev",
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion: v1alpha1.GroupVersion.String(),
+								Kind:       "MonoRepository",
+								Name:       "mono-repository",
+								Controller: ptr.To(true),
+							},
+						},
+					},
+				},
+			},
+			ExpectedResponse: allowed,
+		},
+		"allows deleting an unreferenced MonoRepository": {
+			Request: request(admissionv1.Delete, base.DieReleasePtr()),
+			APIGivenObjects: []client.Object{
+				kustomization("other", map[string]any{
+					"kind": "GitRepository",
+					"name": "mono-repository",
+				}),
+			},
+			ExpectedResponse: allowed,
+		},
+		"rejects deleting a referenced MonoRepository": {
+			Request: request(admissionv1.Delete, base.DieReleasePtr()),
+			APIGivenObjects: []client.Object{
+				kustomization("app", map[string]any{
+					"kind": "MonoRepository",
+					"name": "mono-repository",
+				}),
+			},
+			ExpectedResponse: denied(`MonoRepository dev/mono-repository is referenced by [Kustomization dev/app], set the source.garethjevans.org/force-delete annotation to "true" to delete it anyway`),
+		},
+		"allows deleting a referenced MonoRepository when forced": {
+			Request: request(admissionv1.Delete, base.
+				MetadataDie(func(d *v1.ObjectMetaDie) {
+					d.AddAnnotation(integrity.ForceDeleteAnnotation, "true")
+				}).DieReleasePtr()),
+			APIGivenObjects: []client.Object{
+				kustomization("app", map[string]any{
+					"kind": "MonoRepository",
+					"name": "mono-repository",
+				}),
+			},
+			ExpectedResponse: allowed,
+		},
+	}
+
+	wts.Run(t, scheme, func(t *testing.T, wtc *rtesting.AdmissionWebhookTestCase, c reconcilers.Config) *admission.Webhook {
+		return integrity.MonoRepositoryReferentialIntegrityWebhook(c).Build()
+	})
+}
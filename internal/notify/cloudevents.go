@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	// ArtifactChangedType is the type of the CloudEvent sent when the filtered
+	// artifact of a MonoRepository changes.
+	ArtifactChangedType = "dev.monorepository.artifact.changed"
+
+	// BinaryMode sends the attributes of the CloudEvent as ce- headers, with the
+	// data as the body.
+	BinaryMode = "binary"
+
+	// StructuredMode sends the whole CloudEvent as the JSON body.
+	StructuredMode = "structured"
+
+	specVersion = "1.0"
+)
+
+// CloudEventsSink sends CloudEvents over HTTP. A nil CloudEventsSink sends
+// nothing.
+type CloudEventsSink struct {
+	// URL of the sink, e.g. a Knative broker.
+	URL string
+	// Mode is either BinaryMode or StructuredMode.
+	Mode string
+}
+
+// NewCloudEventsSink returns a sink posting to url in the mode, nil when url is
+// empty.
+func NewCloudEventsSink(url, mode string) (*CloudEventsSink, error) {
+	if url == "" {
+		return nil, nil
+	}
+	if mode != BinaryMode && mode != StructuredMode {
+		return nil, fmt.Errorf("unknown CloudEvents mode %q, must be %s or %s", mode, BinaryMode, StructuredMode)
+	}
+	return &CloudEventsSink{URL: url, Mode: mode}, nil
+}
+
+// ArtifactChanged is the data of an ArtifactChangedType CloudEvent.
+type ArtifactChanged struct {
+	MonoRepository   corev1.ObjectReference `json:"monoRepository"`
+	Revision         string                 `json:"revision"`
+	Checksum         string                 `json:"checksum"`
+	PreviousChecksum string                 `json:"previousChecksum,omitempty"`
+	URL              string                 `json:"url"`
+	Digest           string                 `json:"digest"`
+}
+
+// CloudEvent holds the attributes and data of a CloudEvent.
+type CloudEvent struct {
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// ArtifactChangedEvent converts the change to a CloudEvent, the source is the
+// API path of the MonoRepository.
+func ArtifactChangedEvent(change Change) CloudEvent {
+	return CloudEvent{
+		ID:      string(uuid.NewUUID()),
+		Source:  fmt.Sprintf("/apis/%s/namespaces/%s/monorepositories/%s", v1alpha1.GroupVersion, change.Namespace, change.Name),
+		Type:    ArtifactChangedType,
+		Subject: change.Revision,
+		Time:    change.Timestamp.Time,
+		Data: ArtifactChanged{
+			MonoRepository: corev1.ObjectReference{
+				APIVersion: v1alpha1.GroupVersion.String(),
+				Kind:       "MonoRepository",
+				Namespace:  change.Namespace,
+				Name:       change.Name,
+			},
+			Revision:         change.Revision,
+			Checksum:         change.Checksum,
+			PreviousChecksum: change.PreviousChecksum,
+			URL:              change.URL,
+			Digest:           change.Digest,
+		},
+	}
+}
+
+// Send posts the event to the sink.
+func (s *CloudEventsSink) Send(ctx context.Context, event CloudEvent) error {
+	if s == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	if s.Mode == StructuredMode {
+		body, err := json.Marshal(map[string]any{
+			"specversion":     specVersion,
+			"id":              event.ID,
+			"source":          event.Source,
+			"type":            event.Type,
+			"subject":         event.Subject,
+			"time":            event.Time.UTC().Format(time.RFC3339Nano),
+			"datacontenttype": "application/json",
+			"data":            json.RawMessage(data),
+		})
+		if err != nil {
+			return err
+		}
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json")
+		return post(ctx, s.URL, body, header)
+	}
+
+	header := http.Header{}
+	header.Set("Ce-Specversion", specVersion)
+	header.Set("Ce-Id", event.ID)
+	header.Set("Ce-Source", event.Source)
+	header.Set("Ce-Type", event.Type)
+	header.Set("Ce-Subject", event.Subject)
+	header.Set("Ce-Time", event.Time.UTC().Format(time.RFC3339Nano))
+	return post(ctx, s.URL, data, header)
+}
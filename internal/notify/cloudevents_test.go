@@ -0,0 +1,87 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCloudEventsSink(t *testing.T) {
+	sink, err := notify.NewCloudEventsSink("", notify.BinaryMode)
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+	assert.NoError(t, sink.Send(context.Background(), notify.ArtifactChangedEvent(change)))
+
+	_, err = notify.NewCloudEventsSink("http://broker", "batched")
+	assert.EqualError(t, err, `unknown CloudEvents mode "batched", must be binary or structured`)
+}
+
+func TestCloudEventsBinary(t *testing.T) {
+	server, requests := recorder(t, http.StatusAccepted)
+
+	sink, err := notify.NewCloudEventsSink(server.URL, notify.BinaryMode)
+	assert.NoError(t, err)
+
+	event := notify.ArtifactChangedEvent(change)
+	assert.NoError(t, sink.Send(context.Background(), event))
+	assert.Len(t, *requests, 1)
+
+	header := (*requests)[0].header
+	assert.Equal(t, "1.0", header.Get("Ce-Specversion"))
+	assert.Equal(t, event.ID, header.Get("Ce-Id"))
+	assert.NotEmpty(t, header.Get("Ce-Id"))
+	assert.Equal(t, "/apis/source.garethjevans.org/v1alpha1/namespaces/dev/monorepositories/mono-repository", header.Get("Ce-Source"))
+	assert.Equal(t, "dev.monorepository.artifact.changed", header.Get("Ce-Type"))
+	assert.Equal(t, "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7", header.Get("Ce-Subject"))
+	assert.Equal(t, "2023-05-05T10:17:23Z", header.Get("Ce-Time"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	var data map[string]any
+	assert.NoError(t, json.Unmarshal((*requests)[0].body, &data))
+	assert.Equal(t, map[string]any{
+		"monoRepository": map[string]any{
+			"apiVersion": "source.garethjevans.org/v1alpha1",
+			"kind":       "MonoRepository",
+			"namespace":  "dev",
+			"name":       "mono-repository",
+		},
+		"revision":         "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+		"checksum":         "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+		"previousChecksum": "h1:previous",
+		"url":              "http://localhost:9090/monorepository/dev/mono-repository/e3b0c442.tar.gz",
+		"digest":           "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+	}, data)
+}
+
+func TestCloudEventsStructured(t *testing.T) {
+	server, requests := recorder(t, http.StatusAccepted)
+
+	sink, err := notify.NewCloudEventsSink(server.URL, notify.StructuredMode)
+	assert.NoError(t, err)
+
+	event := notify.ArtifactChangedEvent(change)
+	assert.NoError(t, sink.Send(context.Background(), event))
+	assert.Len(t, *requests, 1)
+
+	assert.Equal(t, "application/cloudevents+json", (*requests)[0].header.Get("Content-Type"))
+	assert.Empty(t, (*requests)[0].header.Get("Ce-Id"))
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal((*requests)[0].body, &body))
+	data := body["data"]
+	delete(body, "data")
+	assert.Equal(t, map[string]any{
+		"specversion":     "1.0",
+		"id":              event.ID,
+		"source":          "/apis/source.garethjevans.org/v1alpha1/namespaces/dev/monorepositories/mono-repository",
+		"type":            "dev.monorepository.artifact.changed",
+		"subject":         "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+		"time":            "2023-05-05T10:17:23Z",
+		"datacontenttype": "application/json",
+	}, body)
+	assert.Equal(t, "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", data.(map[string]any)["checksum"])
+}
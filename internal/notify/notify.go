@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SignatureHeader holds the HMAC-SHA256 signature of a signed webhook payload.
+const SignatureHeader = "X-Signature"
+
+// Client is used to send the notifications, the timeout stops a slow endpoint
+// from holding up the reconcile.
+var Client = &http.Client{Timeout: 15 * time.Second}
+
+// Change describes a change to the filtered artifact of a MonoRepository, it is
+// the payload posted to webhooks.
+type Change struct {
+	Namespace        string                 `json:"namespace"`
+	Name             string                 `json:"name"`
+	Revision         string                 `json:"revision"`
+	Checksum         string                 `json:"checksum"`
+	PreviousChecksum string                 `json:"previousChecksum,omitempty"`
+	URL              string                 `json:"url"`
+	Digest           string                 `json:"digest"`
+	ChangedFiles     *v1alpha1.ChangedFiles `json:"changedFiles,omitempty"`
+	Message          string                 `json:"message"`
+	Timestamp        metav1.Time            `json:"timestamp"`
+}
+
+// Event is the payload accepted by the events endpoint of the Flux
+// notification-controller.
+type Event struct {
+	InvolvedObject      corev1.ObjectReference `json:"involvedObject"`
+	Severity            string                 `json:"severity"`
+	Timestamp           metav1.Time            `json:"timestamp"`
+	Message             string                 `json:"message"`
+	Reason              string                 `json:"reason"`
+	Metadata            map[string]string      `json:"metadata,omitempty"`
+	ReportingController string                 `json:"reportingController"`
+	ReportingInstance   string                 `json:"reportingInstance,omitempty"`
+}
+
+// FluxEvent converts the change to a Flux event. Metadata keys are prefixed
+// with the group of the MonoRepository, as Flux does for its own resources.
+func FluxEvent(change Change, reason string) Event {
+	group := v1alpha1.GroupVersion.Group
+	metadata := map[string]string{
+		group + "/revision": change.Revision,
+		group + "/checksum": change.Checksum,
+		group + "/digest":   change.Digest,
+		group + "/url":      change.URL,
+	}
+	if change.PreviousChecksum != "" {
+		metadata[group+"/previousChecksum"] = change.PreviousChecksum
+	}
+
+	return Event{
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "MonoRepository",
+			Namespace:  change.Namespace,
+			Name:       change.Name,
+		},
+		Severity:            "info",
+		Timestamp:           change.Timestamp,
+		Message:             change.Message,
+		Reason:              reason,
+		Metadata:            metadata,
+		ReportingController: "monorepository-controller",
+	}
+}
+
+// Flux posts the event to the events endpoint of the Flux notification-controller.
+func Flux(ctx context.Context, address string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return post(ctx, address, body, nil)
+}
+
+// Webhook posts the change to url. When token is not empty the payload is
+// signed, with the signature sent in the SignatureHeader.
+func Webhook(ctx context.Context, url string, token []byte, change Change) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if len(token) > 0 {
+		header.Set(SignatureHeader, Sign(token, body))
+	}
+	return post(ctx, url, body, header)
+}
+
+// Sign returns the HMAC-SHA256 signature of body, as sha256=<hex>.
+func Sign(token []byte, body []byte) string {
+	mac := hmac.New(sha256.New, token)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(ctx context.Context, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
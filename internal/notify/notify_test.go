@@ -0,0 +1,106 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/garethjevans/monorepository-controller/internal/notify"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func recorder(t *testing.T, status int) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests = append(requests, request{header: r.Header, body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+var change = notify.Change{
+	Namespace:        "dev",
+	Name:             "mono-repository",
+	Revision:         "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+	Checksum:         "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+	PreviousChecksum: "h1:previous",
+	URL:              "http://localhost:9090/monorepository/dev/mono-repository/e3b0c442.tar.gz",
+	Digest:           "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+	Message:          "Filtered artifact changed",
+	Timestamp:        metav1.NewTime(time.Date(2023, time.May, 5, 10, 17, 23, 0, time.UTC)),
+}
+
+func TestFlux(t *testing.T) {
+	server, requests := recorder(t, http.StatusAccepted)
+
+	assert.NoError(t, notify.Flux(context.Background(), server.URL, notify.FluxEvent(change, "ArtifactChanged")))
+	assert.Len(t, *requests, 1)
+
+	var event map[string]any
+	assert.NoError(t, json.Unmarshal((*requests)[0].body, &event))
+	assert.Equal(t, map[string]any{
+		"involvedObject": map[string]any{
+			"apiVersion": "source.garethjevans.org/v1alpha1",
+			"kind":       "MonoRepository",
+			"namespace":  "dev",
+			"name":       "mono-repository",
+		},
+		"severity":  "info",
+		"timestamp": "2023-05-05T10:17:23Z",
+		"message":   "Filtered artifact changed",
+		"reason":    "ArtifactChanged",
+		"metadata": map[string]any{
+			"source.garethjevans.org/revision":         "main@sha1:68d842cd330410cf0672f862d9a799af4dcdc1d7",
+			"source.garethjevans.org/checksum":         "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+			"source.garethjevans.org/previousChecksum": "h1:previous",
+			"source.garethjevans.org/digest":           "sha256:9addb4c7f3afa99b03f24f9e05cb87d274a63ae9ba30c94f02c75e85d133e9de",
+			"source.garethjevans.org/url":              "http://localhost:9090/monorepository/dev/mono-repository/e3b0c442.tar.gz",
+		},
+		"reportingController": "monorepository-controller",
+	}, event)
+}
+
+func TestWebhook(t *testing.T) {
+	server, requests := recorder(t, http.StatusOK)
+
+	assert.NoError(t, notify.Webhook(context.Background(), server.URL, nil, change))
+	assert.NoError(t, notify.Webhook(context.Background(), server.URL, []byte("s3cr3t"), change))
+	assert.Len(t, *requests, 2)
+
+	unsigned, signed := (*requests)[0], (*requests)[1]
+	assert.Empty(t, unsigned.header.Get(notify.SignatureHeader))
+	assert.Equal(t, "application/json", unsigned.header.Get("Content-Type"))
+	assert.Equal(t, notify.Sign([]byte("s3cr3t"), signed.body), signed.header.Get(notify.SignatureHeader))
+	assert.NotEqual(t, notify.Sign([]byte("other"), signed.body), signed.header.Get(notify.SignatureHeader))
+
+	var payload notify.Change
+	assert.NoError(t, json.Unmarshal(signed.body, &payload))
+	assert.True(t, change.Timestamp.Equal(&payload.Timestamp))
+	payload.Timestamp = change.Timestamp
+	assert.Equal(t, change, payload)
+}
+
+func TestWebhookFailure(t *testing.T) {
+	server, _ := recorder(t, http.StatusInternalServerError)
+
+	err := notify.Webhook(context.Background(), server.URL, nil, change)
+	assert.EqualError(t, err, "unexpected response status 500 Internal Server Error")
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac s3cr3t
+	assert.Equal(t, "sha256=608b0c406f3dda19702d71a048483b8c331283106d80a208e3cf43dbde505286", notify.Sign([]byte("s3cr3t"), []byte("{}")))
+}
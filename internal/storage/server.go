@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Server serves the artifacts held in a Storage over HTTP. It implements
+// manager.Runnable so that it is started and stopped with the manager.
+type Server struct {
+	Storage *Storage
+
+	// Addr is the address the server binds to, e.g. ":9090".
+	Addr string
+}
+
+// Start runs the server until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.Log.WithName("artifact-server")
+
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           http.FileServer(http.Dir(s.Storage.BasePath)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("starting artifact server", "addr", s.Addr, "path", s.Storage.BasePath)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection returns false so that every replica serves its artifacts.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
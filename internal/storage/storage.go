@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Storage manages the filtered artifacts produced by the controller on the local
+// file system and the URLs they are served from.
+type Storage struct {
+	// BasePath is the local directory the artifacts are written to.
+	BasePath string
+
+	// Hostname is the advertised address of the artifact server.
+	Hostname string
+}
+
+// New creates a Storage rooted at basePath, creating the directory if required.
+func New(basePath string, hostname string) (*Storage, error) {
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		return nil, err
+	}
+	return &Storage{BasePath: basePath, Hostname: hostname}, nil
+}
+
+// ArtifactPath returns the relative path of an artifact within the storage.
+func (s *Storage) ArtifactPath(kind, namespace, name, filename string) string {
+	return strings.Join([]string{strings.ToLower(kind), namespace, name, filename}, "/")
+}
+
+// LocalPath returns the absolute path of an artifact on the local file system.
+func (s *Storage) LocalPath(path string) string {
+	return filepath.Join(s.BasePath, filepath.FromSlash(path))
+}
+
+// URL returns the HTTP address the artifact is served from.
+func (s *Storage) URL(path string) string {
+	return fmt.Sprintf("http://%s/%s", s.Hostname, strings.TrimLeft(path, "/"))
+}
+
+// Exists returns true if the artifact is present on the local file system.
+func (s *Storage) Exists(path string) bool {
+	fi, err := os.Stat(s.LocalPath(path))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// ArchiveFiles writes a gzip compressed tarball containing the files, relative to
+// dir, to path within the storage. It returns the digest and size of the tarball.
+func (s *Storage) ArchiveFiles(path string, dir string, files []string) (string, int64, error) {
+	return s.Archive(path, func(tw *tar.Writer) error {
+		sorted := append([]string(nil), files...)
+		sort.Strings(sorted)
+
+		for _, file := range sorted {
+			if err := writeFile(tw, filepath.Join(dir, file), file); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Archive writes a gzip compressed tarball to path within the storage, using fn
+// to add the entries. The tarball is written to a temporary file and renamed into
+// place so that it is never served partially written. It returns the digest and
+// size of the tarball.
+func (s *Storage) Archive(path string, fn func(tw *tar.Writer) error) (string, int64, error) {
+	w, err := s.NewArchiveWriter(filepath.Dir(path))
+	if err != nil {
+		return "", 0, err
+	}
+	defer w.Discard()
+
+	if err := fn(w.Writer); err != nil {
+		return "", 0, err
+	}
+	return w.Commit(path)
+}
+
+// ArchiveWriter writes a gzip compressed tarball to a temporary file within the
+// storage, the tarball is only served once it has been committed to a path.
+type ArchiveWriter struct {
+	*tar.Writer
+
+	storage *Storage
+	tmp     *os.File
+	gw      *gzip.Writer
+	h       hash.Hash
+	cw      *countingWriter
+}
+
+// NewArchiveWriter creates an ArchiveWriter for a tarball that will be committed
+// to a path within dir, so that the tarball can be renamed into place.
+func (s *Storage) NewArchiveWriter(dir string) (*ArchiveWriter, error) {
+	localDir := s.LocalPath(dir)
+	if err := os.MkdirAll(localDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(localDir, ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(tmp, h)}
+	gw := gzip.NewWriter(cw)
+	return &ArchiveWriter{
+		Writer:  tar.NewWriter(gw),
+		storage: s,
+		tmp:     tmp,
+		gw:      gw,
+		h:       h,
+		cw:      cw,
+	}, nil
+}
+
+// Commit completes the tarball and renames it to path, which must be within the
+// directory the writer was created for. It returns the digest and size of the
+// tarball.
+func (w *ArchiveWriter) Commit(path string) (string, int64, error) {
+	if err := w.Writer.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := w.gw.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := w.tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	if err := os.Chmod(w.tmp.Name(), 0o644); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(w.tmp.Name(), w.storage.LocalPath(path)); err != nil {
+		return "", 0, err
+	}
+
+	return fmt.Sprintf("sha256:%x", w.h.Sum(nil)), w.cw.n, nil
+}
+
+// Discard removes a tarball that has not been committed, it has no effect once
+// the tarball has been committed.
+func (w *ArchiveWriter) Discard() {
+	_ = w.tmp.Close()
+	_ = os.Remove(w.tmp.Name())
+}
+
+// GarbageCollect removes all artifacts that are stored alongside path, keeping
+// only path itself and any other paths given in keep.
+func (s *Storage) GarbageCollect(path string, keep ...string) error {
+	localPath := s.LocalPath(path)
+	retain := map[string]bool{filepath.Base(localPath): true}
+	for _, k := range keep {
+		retain[filepath.Base(s.LocalPath(k))] = true
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(localPath))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || retain[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(localPath), entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveAll removes path, and anything it contains, from the storage.
+func (s *Storage) RemoveAll(path string) error {
+	return os.RemoveAll(s.LocalPath(path))
+}
+
+// WriteFile writes data to path within the storage, replacing it atomically.
+func (s *Storage) WriteFile(path string, data []byte) error {
+	localPath := s.LocalPath(path)
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), localPath)
+}
+
+// ReadFile returns the content of path within the storage.
+func (s *Storage) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(s.LocalPath(path))
+}
+
+// writeFile adds a single regular file to the tarball. Modification times and
+// ownership are dropped so that the same content always produces the same digest.
+func writeFile(tw *tar.Writer, src string, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(Header(name, fi.Size())); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Header returns a reproducible tar header for a regular file.
+func Header(name string, size int64) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filepath.ToSlash(name),
+		Mode:     0o644,
+		Size:     size,
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
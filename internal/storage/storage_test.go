@@ -0,0 +1,104 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveFiles(t *testing.T) {
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	path := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "first.tar.gz")
+	assert.Equal(t, "monorepository/dev/mono-repository/first.tar.gz", path)
+	assert.Equal(t, "http://localhost:9090/monorepository/dev/mono-repository/first.tar.gz", s.URL(path))
+
+	digest, size, err := s.ArchiveFiles(path, "../controller/testdata", []string{"dir02/test.txt", "dir01/test.txt"})
+	assert.NoError(t, err)
+	assert.True(t, s.Exists(path))
+
+	fi, err := os.Stat(s.LocalPath(path))
+	assert.NoError(t, err)
+	assert.Equal(t, fi.Size(), size)
+
+	f, err := os.Open(s.LocalPath(path))
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	tr := tar.NewReader(gr)
+
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"dir01/test.txt", "dir02/test.txt"}, names)
+
+	// the same content always produces the same digest
+	second := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "second.tar.gz")
+	digest2, _, err := s.ArchiveFiles(second, "../controller/testdata", []string{"dir01/test.txt", "dir02/test.txt"})
+	assert.NoError(t, err)
+	assert.Equal(t, digest, digest2)
+
+	manifest := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "second.manifest")
+	assert.NoError(t, s.WriteFile(manifest, []byte("manifest")))
+
+	assert.NoError(t, s.GarbageCollect(second, manifest))
+	assert.False(t, s.Exists(path))
+	assert.True(t, s.Exists(second))
+
+	data, err := s.ReadFile(manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, "manifest", string(data))
+
+	assert.NoError(t, s.RemoveAll(s.ArtifactPath("MonoRepository", "dev", "mono-repository", "")))
+	assert.False(t, s.Exists(second))
+	assert.False(t, s.Exists(manifest))
+}
+
+func TestArchiveWriter(t *testing.T) {
+	s, err := storage.New(t.TempDir(), "localhost:9090")
+	assert.NoError(t, err)
+
+	dir := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "")
+	committed, err := s.NewArchiveWriter(dir)
+	assert.NoError(t, err)
+	discarded, err := s.NewArchiveWriter(dir)
+	assert.NoError(t, err)
+
+	for _, w := range []*storage.ArchiveWriter{committed, discarded} {
+		assert.NoError(t, w.WriteHeader(storage.Header("test.txt", 4)))
+		_, err = w.Write([]byte("test"))
+		assert.NoError(t, err)
+	}
+
+	path := s.ArtifactPath("MonoRepository", "dev", "mono-repository", "committed.tar.gz")
+	digest, size, err := committed.Commit(path)
+	assert.NoError(t, err)
+	assert.True(t, s.Exists(path))
+
+	fi, err := os.Stat(s.LocalPath(path))
+	assert.NoError(t, err)
+	assert.Equal(t, fi.Size(), size)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", digest)
+
+	// discarding removes the temporary file but not a committed tarball
+	committed.Discard()
+	discarded.Discard()
+	entries, err := os.ReadDir(s.LocalPath(dir))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "committed.tar.gz", entries[0].Name())
+}
@@ -15,6 +15,15 @@ type _ = v1alpha1.MonoRepositorySpec
 // +die
 type _ = v1alpha1.MonoRepositoryStatus
 
+// +die:object=true
+type _ = v1alpha1.MonoRepositorySet
+
+// +die
+type _ = v1alpha1.MonoRepositorySetSpec
+
+// +die
+type _ = v1alpha1.MonoRepositorySetStatus
+
 func (d *MonoRepositoryStatusDie) ConditionsDie(conditions ...*v1.ConditionDie) *MonoRepositoryStatusDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
 		r.Conditions = make([]metav1.Condition, len(conditions))
@@ -24,6 +33,21 @@ func (d *MonoRepositoryStatusDie) ConditionsDie(conditions ...*v1.ConditionDie)
 	})
 }
 
+func (d *MonoRepositorySetStatusDie) ConditionsDie(conditions ...*v1.ConditionDie) *MonoRepositorySetStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetStatus) {
+		r.Conditions = make([]metav1.Condition, len(conditions))
+		for i := range conditions {
+			r.Conditions[i] = conditions[i].DieRelease()
+		}
+	})
+}
+
 var (
-	MonoRepositoryConditionBlank = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionReady)
+	MonoRepositoryConditionBlank                   = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionReady)
+	MonoRepositoryConditionSourceReadyBlank        = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionSourceReady)
+	MonoRepositoryConditionArtifactDownloadedBlank = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionArtifactDownloaded)
+	MonoRepositoryConditionArtifactFilteredBlank   = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionArtifactFiltered)
+	MonoRepositoryConditionArtifactPublishedBlank  = v1.ConditionBlank.Type(v1alpha1.MonoRepositoryConditionArtifactPublished)
+	MonoRepositorySetConditionBlank                = v1.ConditionBlank.Type(v1alpha1.MonoRepositorySetConditionReady)
+	MonoRepositorySetConditionSourceReadyBlank     = v1.ConditionBlank.Type(v1alpha1.MonoRepositorySetConditionSourceReady)
 )
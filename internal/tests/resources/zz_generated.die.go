@@ -499,18 +499,83 @@ func (d *MonoRepositorySpecDie) DeepCopy() *MonoRepositorySpecDie {
 	}
 }
 
-func (d *MonoRepositorySpecDie) GitRepository(v v1beta2.GitRepositorySpec) *MonoRepositorySpecDie {
+// GitRepository is the spec of the GitRepository that is created to fetch the mono repository.
+func (d *MonoRepositorySpecDie) GitRepository(v *v1beta2.GitRepositorySpec) *MonoRepositorySpecDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
 		r.GitRepository = v
 	})
 }
 
+// OCIRepository is the spec of the OCIRepository that is created to fetch the mono repository, e.g. an artifact pushed with flux push artifact.
+func (d *MonoRepositorySpecDie) OCIRepository(v *v1beta2.OCIRepositorySpec) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.OCIRepository = v
+	})
+}
+
+// Bucket is the spec of the Bucket that is created to fetch the mono repository from an S3 compatible storage.
+func (d *MonoRepositorySpecDie) Bucket(v *v1beta2.BucketSpec) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.Bucket = v
+	})
+}
+
+// SourceRef references an existing GitRepository, OCIRepository or Bucket that is watched, but not owned, by the MonoRepository. This allows many MonoRepositories to share a single clone of the mono repository.
+func (d *MonoRepositorySpecDie) SourceRef(v *v1alpha1.SourceReference) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.SourceRef = v
+	})
+}
+
+// Include is a gitignore style list of the files used to calculate the checksum, exclusions are expressed as ! negations and ordering matters.
 func (d *MonoRepositorySpecDie) Include(v string) *MonoRepositorySpecDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
 		r.Include = v
 	})
 }
 
+// IncludeFrom is the path of a file within the artifact, e.g. services/foo/.monorepo-include, holding additional gitignore style rules that are appended to Include. Patterns are relative to the root of the artifact.
+func (d *MonoRepositorySpecDie) IncludeFrom(v string) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.IncludeFrom = v
+	})
+}
+
+// Includes is a list of gitignore style patterns, a file is selected when it matches Include or any of these patterns.
+func (d *MonoRepositorySpecDie) Includes(v ...string) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.Includes = v
+	})
+}
+
+// Exclude is a list of gitignore style patterns, a file matching any of these patterns is never selected, regardless of the include rules.
+func (d *MonoRepositorySpecDie) Exclude(v ...string) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.Exclude = v
+	})
+}
+
+// EmptyMatchPolicy is applied when the include rules do not select any file of the artifact. Fail marks the MonoRepository as not ready, Warn emits a Warning event and publishes the empty artifact, and Allow publishes it silently. Defaults to Warn.
+func (d *MonoRepositorySpecDie) EmptyMatchPolicy(v v1alpha1.EmptyMatchPolicy) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.EmptyMatchPolicy = v
+	})
+}
+
+// Components are additional named outputs of the mono repository, each component is filtered with its own rules from the same download and has an independent checksum and artifact.
+func (d *MonoRepositorySpecDie) Components(v map[string]v1alpha1.Component) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.Components = v
+	})
+}
+
+// Notify configures the notifications that are sent whenever the filtered artifact changes.
+func (d *MonoRepositorySpecDie) Notify(v *v1alpha1.Notify) *MonoRepositorySpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySpec) {
+		r.Notify = v
+	})
+}
+
 var MonoRepositoryStatusBlank = (&MonoRepositoryStatusDie{}).DieFeed(v1alpha1.MonoRepositoryStatus{})
 
 type MonoRepositoryStatusDie struct {
@@ -713,22 +778,740 @@ func (d *MonoRepositoryStatusDie) Artifact(v *v1alpha1.Artifact) *MonoRepository
 	})
 }
 
-// ObservedInclude is the observed list of GitRepository resources used to calculate the checksum for this artifact
+// ObservedInclude is the effective include rules used to calculate the checksum for this artifact, annotated with where each rule came from
 func (d *MonoRepositoryStatusDie) ObservedInclude(v string) *MonoRepositoryStatusDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
 		r.ObservedInclude = v
 	})
 }
 
-// ObservedFileList is the file list used to calculate the checksum for this artifact
+// ObservedFileList is a preview of the file list used to calculate the checksum for this artifact, holding at most 100 paths. The complete list is in the file manifest.
 func (d *MonoRepositoryStatusDie) ObservedFileList(v string) *MonoRepositoryStatusDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
 		r.ObservedFileList = v
 	})
 }
 
+// FileManifest references the manifest of the files used to calculate the checksum for this artifact.
+func (d *MonoRepositoryStatusDie) FileManifest(v *v1alpha1.FileManifest) *MonoRepositoryStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
+		r.FileManifest = v
+	})
+}
+
+// UnmatchedIncludes lists the include rules, and includes patterns, that did not match any file of the artifact.
+func (d *MonoRepositoryStatusDie) UnmatchedIncludes(v ...string) *MonoRepositoryStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
+		r.UnmatchedIncludes = v
+	})
+}
+
+// ChangedFiles summarises the files that differ between the previous and the current artifact.
+func (d *MonoRepositoryStatusDie) ChangedFiles(v *v1alpha1.ChangedFiles) *MonoRepositoryStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
+		r.ChangedFiles = v
+	})
+}
+
+// NotifiedChecksum is the checksum of the artifact the last change notifications were sent for.
+func (d *MonoRepositoryStatusDie) NotifiedChecksum(v string) *MonoRepositoryStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
+		r.NotifiedChecksum = v
+	})
+}
+
+// Components holds the observed state of each component, keyed by name.
+func (d *MonoRepositoryStatusDie) Components(v map[string]v1alpha1.ComponentStatus) *MonoRepositoryStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
+		r.Components = v
+	})
+}
+
 func (d *MonoRepositoryStatusDie) ReconcileRequestStatus(v meta.ReconcileRequestStatus) *MonoRepositoryStatusDie {
 	return d.DieStamp(func(r *v1alpha1.MonoRepositoryStatus) {
 		r.ReconcileRequestStatus = v
 	})
 }
+
+var MonoRepositorySetBlank = (&MonoRepositorySetDie{}).DieFeed(v1alpha1.MonoRepositorySet{})
+
+type MonoRepositorySetDie struct {
+	v1.FrozenObjectMeta
+	mutable bool
+	r       v1alpha1.MonoRepositorySet
+}
+
+// DieImmutable returns a new die for the current die's state that is either mutable (`false`) or immutable (`true`).
+func (d *MonoRepositorySetDie) DieImmutable(immutable bool) *MonoRepositorySetDie {
+	if d.mutable == !immutable {
+		return d
+	}
+	d = d.DeepCopy()
+	d.mutable = !immutable
+	return d
+}
+
+// DieFeed returns a new die with the provided resource.
+func (d *MonoRepositorySetDie) DieFeed(r v1alpha1.MonoRepositorySet) *MonoRepositorySetDie {
+	if d.mutable {
+		d.FrozenObjectMeta = v1.FreezeObjectMeta(r.ObjectMeta)
+		d.r = r
+		return d
+	}
+	return &MonoRepositorySetDie{
+		FrozenObjectMeta: v1.FreezeObjectMeta(r.ObjectMeta),
+		mutable:          d.mutable,
+		r:                r,
+	}
+}
+
+// DieFeedPtr returns a new die with the provided resource pointer. If the resource is nil, the empty value is used instead.
+func (d *MonoRepositorySetDie) DieFeedPtr(r *v1alpha1.MonoRepositorySet) *MonoRepositorySetDie {
+	if r == nil {
+		r = &v1alpha1.MonoRepositorySet{}
+	}
+	return d.DieFeed(*r)
+}
+
+// DieFeedJSON returns a new die with the provided JSON. Panics on error.
+func (d *MonoRepositorySetDie) DieFeedJSON(j []byte) *MonoRepositorySetDie {
+	r := v1alpha1.MonoRepositorySet{}
+	if err := json.Unmarshal(j, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAML returns a new die with the provided YAML. Panics on error.
+func (d *MonoRepositorySetDie) DieFeedYAML(y []byte) *MonoRepositorySetDie {
+	r := v1alpha1.MonoRepositorySet{}
+	if err := yaml.Unmarshal(y, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAMLFile returns a new die loading YAML from a file path. Panics on error.
+func (d *MonoRepositorySetDie) DieFeedYAMLFile(name string) *MonoRepositorySetDie {
+	y, err := osx.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedYAML(y)
+}
+
+// DieFeedRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetDie) DieFeedRawExtension(raw runtime.RawExtension) *MonoRepositorySetDie {
+	j, err := json.Marshal(raw)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedJSON(j)
+}
+
+// DieRelease returns the resource managed by the die.
+func (d *MonoRepositorySetDie) DieRelease() v1alpha1.MonoRepositorySet {
+	if d.mutable {
+		return d.r
+	}
+	return *d.r.DeepCopy()
+}
+
+// DieReleasePtr returns a pointer to the resource managed by the die.
+func (d *MonoRepositorySetDie) DieReleasePtr() *v1alpha1.MonoRepositorySet {
+	r := d.DieRelease()
+	return &r
+}
+
+// DieReleaseUnstructured returns the resource managed by the die as an unstructured object. Panics on error.
+func (d *MonoRepositorySetDie) DieReleaseUnstructured() *unstructured.Unstructured {
+	r := d.DieReleasePtr()
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(r)
+	if err != nil {
+		panic(err)
+	}
+	return &unstructured.Unstructured{
+		Object: u,
+	}
+}
+
+// DieReleaseJSON returns the resource managed by the die as JSON. Panics on error.
+func (d *MonoRepositorySetDie) DieReleaseJSON() []byte {
+	r := d.DieReleasePtr()
+	j, err := json.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// DieReleaseYAML returns the resource managed by the die as YAML. Panics on error.
+func (d *MonoRepositorySetDie) DieReleaseYAML() []byte {
+	r := d.DieReleasePtr()
+	y, err := yaml.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return y
+}
+
+// DieReleaseRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetDie) DieReleaseRawExtension() runtime.RawExtension {
+	j := d.DieReleaseJSON()
+	raw := runtime.RawExtension{}
+	if err := json.Unmarshal(j, &raw); err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// DieStamp returns a new die with the resource passed to the callback function. The resource is mutable.
+func (d *MonoRepositorySetDie) DieStamp(fn func(r *v1alpha1.MonoRepositorySet)) *MonoRepositorySetDie {
+	r := d.DieRelease()
+	fn(&r)
+	return d.DieFeed(r)
+}
+
+// Experimental: DieStampAt uses a JSON path (http://goessner.net/articles/JsonPath/) expression to stamp portions of the resource. The callback is invoked with each JSON path match. Panics if the callback function does not accept a single argument of the same type or a pointer to that type as found on the resource at the target location.
+//
+// Future iterations will improve type coercion from the resource to the callback argument.
+func (d *MonoRepositorySetDie) DieStampAt(jp string, fn interface{}) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		if ni := reflectx.ValueOf(fn).Type().NumIn(); ni != 1 {
+			panic(fmtx.Errorf("callback function must have 1 input parameters, found %d", ni))
+		}
+		if no := reflectx.ValueOf(fn).Type().NumOut(); no != 0 {
+			panic(fmtx.Errorf("callback function must have 0 output parameters, found %d", no))
+		}
+
+		cp := jsonpath.New("")
+		if err := cp.Parse(fmtx.Sprintf("{%s}", jp)); err != nil {
+			panic(err)
+		}
+		cr, err := cp.FindResults(r)
+		if err != nil {
+			// errors are expected if a path is not found
+			return
+		}
+		for _, cv := range cr[0] {
+			arg0t := reflectx.ValueOf(fn).Type().In(0)
+
+			var args []reflectx.Value
+			if cv.Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv}
+			} else if cv.CanAddr() && cv.Addr().Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv.Addr()}
+			} else {
+				panic(fmtx.Errorf("callback function must accept value of type %q, found type %q", cv.Type(), arg0t))
+			}
+
+			reflectx.ValueOf(fn).Call(args)
+		}
+	})
+}
+
+// DieWith returns a new die after passing the current die to the callback function. The passed die is mutable.
+func (d *MonoRepositorySetDie) DieWith(fns ...func(d *MonoRepositorySetDie)) *MonoRepositorySetDie {
+	nd := MonoRepositorySetBlank.DieFeed(d.DieRelease()).DieImmutable(false)
+	for _, fn := range fns {
+		if fn != nil {
+			fn(nd)
+		}
+	}
+	return d.DieFeed(nd.DieRelease())
+}
+
+// DeepCopy returns a new die with equivalent state. Useful for snapshotting a mutable die.
+func (d *MonoRepositorySetDie) DeepCopy() *MonoRepositorySetDie {
+	r := *d.r.DeepCopy()
+	return &MonoRepositorySetDie{
+		FrozenObjectMeta: v1.FreezeObjectMeta(r.ObjectMeta),
+		mutable:          d.mutable,
+		r:                r,
+	}
+}
+
+var _ runtime.Object = (*MonoRepositorySetDie)(nil)
+
+func (d *MonoRepositorySetDie) DeepCopyObject() runtime.Object {
+	return d.r.DeepCopy()
+}
+
+func (d *MonoRepositorySetDie) GetObjectKind() schema.ObjectKind {
+	r := d.DieRelease()
+	return r.GetObjectKind()
+}
+
+func (d *MonoRepositorySetDie) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.r)
+}
+
+func (d *MonoRepositorySetDie) UnmarshalJSON(b []byte) error {
+	if d == MonoRepositorySetBlank {
+		return fmtx.Errorf("cannot unmarshal into the blank die, create a copy first")
+	}
+	if !d.mutable {
+		return fmtx.Errorf("cannot unmarshal into immutable dies, create a mutable version first")
+	}
+	r := &v1alpha1.MonoRepositorySet{}
+	err := json.Unmarshal(b, r)
+	*d = *d.DieFeed(*r)
+	return err
+}
+
+// APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources
+func (d *MonoRepositorySetDie) APIVersion(v string) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		r.APIVersion = v
+	})
+}
+
+// Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds
+func (d *MonoRepositorySetDie) Kind(v string) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		r.Kind = v
+	})
+}
+
+// MetadataDie stamps the resource's ObjectMeta field with a mutable die.
+func (d *MonoRepositorySetDie) MetadataDie(fn func(d *v1.ObjectMetaDie)) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		d := v1.ObjectMetaBlank.DieImmutable(false).DieFeed(r.ObjectMeta)
+		fn(d)
+		r.ObjectMeta = d.DieRelease()
+	})
+}
+
+// SpecDie stamps the resource's spec field with a mutable die.
+func (d *MonoRepositorySetDie) SpecDie(fn func(d *MonoRepositorySetSpecDie)) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		d := MonoRepositorySetSpecBlank.DieImmutable(false).DieFeed(r.Spec)
+		fn(d)
+		r.Spec = d.DieRelease()
+	})
+}
+
+// StatusDie stamps the resource's status field with a mutable die.
+func (d *MonoRepositorySetDie) StatusDie(fn func(d *MonoRepositorySetStatusDie)) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		d := MonoRepositorySetStatusBlank.DieImmutable(false).DieFeed(r.Status)
+		fn(d)
+		r.Status = d.DieRelease()
+	})
+}
+
+func (d *MonoRepositorySetDie) Spec(v v1alpha1.MonoRepositorySetSpec) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		r.Spec = v
+	})
+}
+
+func (d *MonoRepositorySetDie) Status(v v1alpha1.MonoRepositorySetStatus) *MonoRepositorySetDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySet) {
+		r.Status = v
+	})
+}
+
+var MonoRepositorySetSpecBlank = (&MonoRepositorySetSpecDie{}).DieFeed(v1alpha1.MonoRepositorySetSpec{})
+
+type MonoRepositorySetSpecDie struct {
+	mutable bool
+	r       v1alpha1.MonoRepositorySetSpec
+}
+
+// DieImmutable returns a new die for the current die's state that is either mutable (`false`) or immutable (`true`).
+func (d *MonoRepositorySetSpecDie) DieImmutable(immutable bool) *MonoRepositorySetSpecDie {
+	if d.mutable == !immutable {
+		return d
+	}
+	d = d.DeepCopy()
+	d.mutable = !immutable
+	return d
+}
+
+// DieFeed returns a new die with the provided resource.
+func (d *MonoRepositorySetSpecDie) DieFeed(r v1alpha1.MonoRepositorySetSpec) *MonoRepositorySetSpecDie {
+	if d.mutable {
+		d.r = r
+		return d
+	}
+	return &MonoRepositorySetSpecDie{
+		mutable: d.mutable,
+		r:       r,
+	}
+}
+
+// DieFeedPtr returns a new die with the provided resource pointer. If the resource is nil, the empty value is used instead.
+func (d *MonoRepositorySetSpecDie) DieFeedPtr(r *v1alpha1.MonoRepositorySetSpec) *MonoRepositorySetSpecDie {
+	if r == nil {
+		r = &v1alpha1.MonoRepositorySetSpec{}
+	}
+	return d.DieFeed(*r)
+}
+
+// DieFeedJSON returns a new die with the provided JSON. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieFeedJSON(j []byte) *MonoRepositorySetSpecDie {
+	r := v1alpha1.MonoRepositorySetSpec{}
+	if err := json.Unmarshal(j, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAML returns a new die with the provided YAML. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieFeedYAML(y []byte) *MonoRepositorySetSpecDie {
+	r := v1alpha1.MonoRepositorySetSpec{}
+	if err := yaml.Unmarshal(y, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAMLFile returns a new die loading YAML from a file path. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieFeedYAMLFile(name string) *MonoRepositorySetSpecDie {
+	y, err := osx.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedYAML(y)
+}
+
+// DieFeedRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieFeedRawExtension(raw runtime.RawExtension) *MonoRepositorySetSpecDie {
+	j, err := json.Marshal(raw)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedJSON(j)
+}
+
+// DieRelease returns the resource managed by the die.
+func (d *MonoRepositorySetSpecDie) DieRelease() v1alpha1.MonoRepositorySetSpec {
+	if d.mutable {
+		return d.r
+	}
+	return *d.r.DeepCopy()
+}
+
+// DieReleasePtr returns a pointer to the resource managed by the die.
+func (d *MonoRepositorySetSpecDie) DieReleasePtr() *v1alpha1.MonoRepositorySetSpec {
+	r := d.DieRelease()
+	return &r
+}
+
+// DieReleaseJSON returns the resource managed by the die as JSON. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieReleaseJSON() []byte {
+	r := d.DieReleasePtr()
+	j, err := json.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// DieReleaseYAML returns the resource managed by the die as YAML. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieReleaseYAML() []byte {
+	r := d.DieReleasePtr()
+	y, err := yaml.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return y
+}
+
+// DieReleaseRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetSpecDie) DieReleaseRawExtension() runtime.RawExtension {
+	j := d.DieReleaseJSON()
+	raw := runtime.RawExtension{}
+	if err := json.Unmarshal(j, &raw); err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// DieStamp returns a new die with the resource passed to the callback function. The resource is mutable.
+func (d *MonoRepositorySetSpecDie) DieStamp(fn func(r *v1alpha1.MonoRepositorySetSpec)) *MonoRepositorySetSpecDie {
+	r := d.DieRelease()
+	fn(&r)
+	return d.DieFeed(r)
+}
+
+// Experimental: DieStampAt uses a JSON path (http://goessner.net/articles/JsonPath/) expression to stamp portions of the resource. The callback is invoked with each JSON path match. Panics if the callback function does not accept a single argument of the same type or a pointer to that type as found on the resource at the target location.
+//
+// Future iterations will improve type coercion from the resource to the callback argument.
+func (d *MonoRepositorySetSpecDie) DieStampAt(jp string, fn interface{}) *MonoRepositorySetSpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetSpec) {
+		if ni := reflectx.ValueOf(fn).Type().NumIn(); ni != 1 {
+			panic(fmtx.Errorf("callback function must have 1 input parameters, found %d", ni))
+		}
+		if no := reflectx.ValueOf(fn).Type().NumOut(); no != 0 {
+			panic(fmtx.Errorf("callback function must have 0 output parameters, found %d", no))
+		}
+
+		cp := jsonpath.New("")
+		if err := cp.Parse(fmtx.Sprintf("{%s}", jp)); err != nil {
+			panic(err)
+		}
+		cr, err := cp.FindResults(r)
+		if err != nil {
+			// errors are expected if a path is not found
+			return
+		}
+		for _, cv := range cr[0] {
+			arg0t := reflectx.ValueOf(fn).Type().In(0)
+
+			var args []reflectx.Value
+			if cv.Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv}
+			} else if cv.CanAddr() && cv.Addr().Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv.Addr()}
+			} else {
+				panic(fmtx.Errorf("callback function must accept value of type %q, found type %q", cv.Type(), arg0t))
+			}
+
+			reflectx.ValueOf(fn).Call(args)
+		}
+	})
+}
+
+// DieWith returns a new die after passing the current die to the callback function. The passed die is mutable.
+func (d *MonoRepositorySetSpecDie) DieWith(fns ...func(d *MonoRepositorySetSpecDie)) *MonoRepositorySetSpecDie {
+	nd := MonoRepositorySetSpecBlank.DieFeed(d.DieRelease()).DieImmutable(false)
+	for _, fn := range fns {
+		if fn != nil {
+			fn(nd)
+		}
+	}
+	return d.DieFeed(nd.DieRelease())
+}
+
+// DeepCopy returns a new die with equivalent state. Useful for snapshotting a mutable die.
+func (d *MonoRepositorySetSpecDie) DeepCopy() *MonoRepositorySetSpecDie {
+	r := *d.r.DeepCopy()
+	return &MonoRepositorySetSpecDie{
+		mutable: d.mutable,
+		r:       r,
+	}
+}
+
+// SourceRef references the GitRepository, OCIRepository or Bucket holding the mono repository, it is shared by every generated MonoRepository.
+func (d *MonoRepositorySetSpecDie) SourceRef(v v1alpha1.SourceReference) *MonoRepositorySetSpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetSpec) {
+		r.SourceRef = v
+	})
+}
+
+// Generator selects the directories a MonoRepository is generated for.
+func (d *MonoRepositorySetSpecDie) Generator(v v1alpha1.DirectoryGenerator) *MonoRepositorySetSpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetSpec) {
+		r.Generator = v
+	})
+}
+
+// Template describes the MonoRepository generated for each directory.
+func (d *MonoRepositorySetSpecDie) Template(v v1alpha1.MonoRepositoryTemplate) *MonoRepositorySetSpecDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetSpec) {
+		r.Template = v
+	})
+}
+
+var MonoRepositorySetStatusBlank = (&MonoRepositorySetStatusDie{}).DieFeed(v1alpha1.MonoRepositorySetStatus{})
+
+type MonoRepositorySetStatusDie struct {
+	mutable bool
+	r       v1alpha1.MonoRepositorySetStatus
+}
+
+// DieImmutable returns a new die for the current die's state that is either mutable (`false`) or immutable (`true`).
+func (d *MonoRepositorySetStatusDie) DieImmutable(immutable bool) *MonoRepositorySetStatusDie {
+	if d.mutable == !immutable {
+		return d
+	}
+	d = d.DeepCopy()
+	d.mutable = !immutable
+	return d
+}
+
+// DieFeed returns a new die with the provided resource.
+func (d *MonoRepositorySetStatusDie) DieFeed(r v1alpha1.MonoRepositorySetStatus) *MonoRepositorySetStatusDie {
+	if d.mutable {
+		d.r = r
+		return d
+	}
+	return &MonoRepositorySetStatusDie{
+		mutable: d.mutable,
+		r:       r,
+	}
+}
+
+// DieFeedPtr returns a new die with the provided resource pointer. If the resource is nil, the empty value is used instead.
+func (d *MonoRepositorySetStatusDie) DieFeedPtr(r *v1alpha1.MonoRepositorySetStatus) *MonoRepositorySetStatusDie {
+	if r == nil {
+		r = &v1alpha1.MonoRepositorySetStatus{}
+	}
+	return d.DieFeed(*r)
+}
+
+// DieFeedJSON returns a new die with the provided JSON. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieFeedJSON(j []byte) *MonoRepositorySetStatusDie {
+	r := v1alpha1.MonoRepositorySetStatus{}
+	if err := json.Unmarshal(j, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAML returns a new die with the provided YAML. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieFeedYAML(y []byte) *MonoRepositorySetStatusDie {
+	r := v1alpha1.MonoRepositorySetStatus{}
+	if err := yaml.Unmarshal(y, &r); err != nil {
+		panic(err)
+	}
+	return d.DieFeed(r)
+}
+
+// DieFeedYAMLFile returns a new die loading YAML from a file path. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieFeedYAMLFile(name string) *MonoRepositorySetStatusDie {
+	y, err := osx.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedYAML(y)
+}
+
+// DieFeedRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieFeedRawExtension(raw runtime.RawExtension) *MonoRepositorySetStatusDie {
+	j, err := json.Marshal(raw)
+	if err != nil {
+		panic(err)
+	}
+	return d.DieFeedJSON(j)
+}
+
+// DieRelease returns the resource managed by the die.
+func (d *MonoRepositorySetStatusDie) DieRelease() v1alpha1.MonoRepositorySetStatus {
+	if d.mutable {
+		return d.r
+	}
+	return *d.r.DeepCopy()
+}
+
+// DieReleasePtr returns a pointer to the resource managed by the die.
+func (d *MonoRepositorySetStatusDie) DieReleasePtr() *v1alpha1.MonoRepositorySetStatus {
+	r := d.DieRelease()
+	return &r
+}
+
+// DieReleaseJSON returns the resource managed by the die as JSON. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieReleaseJSON() []byte {
+	r := d.DieReleasePtr()
+	j, err := json.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return j
+}
+
+// DieReleaseYAML returns the resource managed by the die as YAML. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieReleaseYAML() []byte {
+	r := d.DieReleasePtr()
+	y, err := yaml.Marshal(r)
+	if err != nil {
+		panic(err)
+	}
+	return y
+}
+
+// DieReleaseRawExtension returns the resource managed by the die as an raw extension. Panics on error.
+func (d *MonoRepositorySetStatusDie) DieReleaseRawExtension() runtime.RawExtension {
+	j := d.DieReleaseJSON()
+	raw := runtime.RawExtension{}
+	if err := json.Unmarshal(j, &raw); err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// DieStamp returns a new die with the resource passed to the callback function. The resource is mutable.
+func (d *MonoRepositorySetStatusDie) DieStamp(fn func(r *v1alpha1.MonoRepositorySetStatus)) *MonoRepositorySetStatusDie {
+	r := d.DieRelease()
+	fn(&r)
+	return d.DieFeed(r)
+}
+
+// Experimental: DieStampAt uses a JSON path (http://goessner.net/articles/JsonPath/) expression to stamp portions of the resource. The callback is invoked with each JSON path match. Panics if the callback function does not accept a single argument of the same type or a pointer to that type as found on the resource at the target location.
+//
+// Future iterations will improve type coercion from the resource to the callback argument.
+func (d *MonoRepositorySetStatusDie) DieStampAt(jp string, fn interface{}) *MonoRepositorySetStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetStatus) {
+		if ni := reflectx.ValueOf(fn).Type().NumIn(); ni != 1 {
+			panic(fmtx.Errorf("callback function must have 1 input parameters, found %d", ni))
+		}
+		if no := reflectx.ValueOf(fn).Type().NumOut(); no != 0 {
+			panic(fmtx.Errorf("callback function must have 0 output parameters, found %d", no))
+		}
+
+		cp := jsonpath.New("")
+		if err := cp.Parse(fmtx.Sprintf("{%s}", jp)); err != nil {
+			panic(err)
+		}
+		cr, err := cp.FindResults(r)
+		if err != nil {
+			// errors are expected if a path is not found
+			return
+		}
+		for _, cv := range cr[0] {
+			arg0t := reflectx.ValueOf(fn).Type().In(0)
+
+			var args []reflectx.Value
+			if cv.Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv}
+			} else if cv.CanAddr() && cv.Addr().Type().AssignableTo(arg0t) {
+				args = []reflectx.Value{cv.Addr()}
+			} else {
+				panic(fmtx.Errorf("callback function must accept value of type %q, found type %q", cv.Type(), arg0t))
+			}
+
+			reflectx.ValueOf(fn).Call(args)
+		}
+	})
+}
+
+// DieWith returns a new die after passing the current die to the callback function. The passed die is mutable.
+func (d *MonoRepositorySetStatusDie) DieWith(fns ...func(d *MonoRepositorySetStatusDie)) *MonoRepositorySetStatusDie {
+	nd := MonoRepositorySetStatusBlank.DieFeed(d.DieRelease()).DieImmutable(false)
+	for _, fn := range fns {
+		if fn != nil {
+			fn(nd)
+		}
+	}
+	return d.DieFeed(nd.DieRelease())
+}
+
+// DeepCopy returns a new die with equivalent state. Useful for snapshotting a mutable die.
+func (d *MonoRepositorySetStatusDie) DeepCopy() *MonoRepositorySetStatusDie {
+	r := *d.r.DeepCopy()
+	return &MonoRepositorySetStatusDie{
+		mutable: d.mutable,
+		r:       r,
+	}
+}
+func (d *MonoRepositorySetStatusDie) Status(v apis.Status) *MonoRepositorySetStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetStatus) {
+		r.Status = v
+	})
+}
+
+// Directories is the list of directories a MonoRepository has been generated for.
+func (d *MonoRepositorySetStatusDie) Directories(v ...string) *MonoRepositorySetStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetStatus) {
+		r.Directories = v
+	})
+}
+
+// ObservedRevision is the revision of the artifact the directories were selected from.
+func (d *MonoRepositorySetStatusDie) ObservedRevision(v string) *MonoRepositorySetStatusDie {
+	return d.DieStamp(func(r *v1alpha1.MonoRepositorySetStatus) {
+		r.ObservedRevision = v
+	})
+}
@@ -52,3 +52,30 @@ func TestMonoRepositoryStatusDie_MissingMethods(t *testingx.T) {
 		t.Errorf("found missing fields for MonoRepositoryStatusDie: %s", diff.List())
 	}
 }
+
+func TestMonoRepositorySetDie_MissingMethods(t *testingx.T) {
+	die := MonoRepositorySetBlank
+	ignore := []string{"TypeMeta", "ObjectMeta"}
+	diff := testing.DieFieldDiff(die).Delete(ignore...)
+	if diff.Len() != 0 {
+		t.Errorf("found missing fields for MonoRepositorySetDie: %s", diff.List())
+	}
+}
+
+func TestMonoRepositorySetSpecDie_MissingMethods(t *testingx.T) {
+	die := MonoRepositorySetSpecBlank
+	ignore := []string{}
+	diff := testing.DieFieldDiff(die).Delete(ignore...)
+	if diff.Len() != 0 {
+		t.Errorf("found missing fields for MonoRepositorySetSpecDie: %s", diff.List())
+	}
+}
+
+func TestMonoRepositorySetStatusDie_MissingMethods(t *testingx.T) {
+	die := MonoRepositorySetStatusBlank
+	ignore := []string{}
+	diff := testing.DieFieldDiff(die).Delete(ignore...)
+	if diff.Len() != 0 {
+		t.Errorf("found missing fields for MonoRepositorySetStatusDie: %s", diff.List())
+	}
+}
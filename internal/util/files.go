@@ -1,13 +1,17 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/fluxcd/pkg/sourceignore"
 	"golang.org/x/mod/sumdb/dirhash"
 )
 
@@ -21,16 +25,48 @@ func HashFiles(list []string, dir string) (string, error) {
 	})
 }
 
+// HashDir hashes the files in dir accepted by match, the manifest is identical
+// to that of HashTarGz over a tarball of the same files.
+func HashDir(dir string, match func(name string) bool) (Manifest, error) {
+	files, err := ListFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	for _, file := range files {
+		if !match(file) {
+			continue
+		}
+		sum, err := hashFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		manifest[file] = sum
+	}
+	return manifest, nil
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func FilterFileList(list []string, include string) []string {
-	var domain []string
-	patterns := sourceignore.ReadPatterns(strings.NewReader(include), domain)
-	matcher := sourceignore.NewDefaultMatcher(patterns, domain)
+	match := NewMatcher(include)
 
 	var filtered []string
 	for _, file := range list {
-		fileParts := strings.Split(file, string(filepath.Separator))
-
-		if matcher.Match(fileParts, false) {
+		if match(filepath.ToSlash(file)) {
 			filtered = append(filtered, file)
 		}
 	}
@@ -38,6 +74,38 @@ func FilterFileList(list []string, include string) []string {
 	return filtered
 }
 
+// MatchDirectories returns the sorted directories, derived from the paths in list,
+// that match the glob pattern. When containing is set only directories holding a
+// file of that name are returned.
+func MatchDirectories(list []string, pattern string, containing string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, file := range list {
+		file = filepath.ToSlash(file)
+		files[file] = true
+		for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	var matched []string
+	for dir := range dirs {
+		if ok, _ := path.Match(pattern, dir); !ok {
+			continue
+		}
+		if containing != "" && !files[path.Join(dir, containing)] {
+			continue
+		}
+		matched = append(matched, dir)
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
 // DownloadFile will download a url to a local file. It's efficient because it will
 // write as it downloads and not load the whole file into memory.
 func DownloadFile(filepath string, url string) error {
@@ -48,6 +116,10 @@ func DownloadFile(filepath string, url string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to download %s: %s", url, resp.Status)
+	}
+
 	// Create the file
 	out, err := os.Create(filepath)
 	if err != nil {
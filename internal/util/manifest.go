@@ -0,0 +1,90 @@
+package util
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Manifest maps the slash separated path of each file to the hex encoded sha256
+// of its content.
+type Manifest map[string]string
+
+// Files returns the sorted paths of the files in the manifest.
+func (m Manifest) Files() []string {
+	files := make([]string, 0, len(m))
+	for file := range m {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Hash1 returns the "h1:" checksum of the files in the manifest, this is
+// identical to dirhash.Hash1 calculated over the same files.
+func (m Manifest) Hash1() (string, error) {
+	h := sha256.New()
+	if _, err := m.WriteTo(h); err != nil {
+		return "", err
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteTo writes the manifest in the dirhash summary format, one
+// "<sha256>  <path>" line per file.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, file := range m.Files() {
+		if strings.Contains(file, "\n") {
+			return n, errors.New("dirhash: filenames with newlines are not supported")
+		}
+		c, err := fmt.Fprintf(w, "%s  %s\n", m[file], file)
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadManifest parses a manifest written by Manifest.WriteTo.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m := Manifest{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sum, file, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid manifest line %q", scanner.Text())
+		}
+		m[file] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Diff returns the sorted paths that have been added, removed or modified in m
+// compared to previous.
+func (m Manifest) Diff(previous Manifest) (added, removed, modified []string) {
+	for _, file := range m.Files() {
+		sum, ok := previous[file]
+		switch {
+		case !ok:
+			added = append(added, file)
+		case sum != m[file]:
+			modified = append(modified, file)
+		}
+	}
+	for _, file := range previous.Files() {
+		if _, ok := m[file]; !ok {
+			removed = append(removed, file)
+		}
+	}
+	return added, removed, modified
+}
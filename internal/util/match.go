@@ -0,0 +1,140 @@
+package util
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fluxcd/pkg/sourceignore"
+)
+
+// NewMatcher returns a function reporting whether a slash separated path is
+// selected by the gitignore style include rules.
+func NewMatcher(include string) func(name string) bool {
+	var domain []string
+	patterns := sourceignore.ReadPatterns(strings.NewReader(include), domain)
+	matcher := sourceignore.NewDefaultMatcher(patterns, domain)
+
+	return func(name string) bool {
+		return matcher.Match(strings.Split(name, "/"), false)
+	}
+}
+
+// NewFilter returns a function reporting whether a slash separated path is
+// selected. A path is selected when it matches the include rules or any of the
+// includes patterns, and none of the exclude patterns. Each pattern in includes
+// and exclude is evaluated on its own so that their order does not matter.
+func NewFilter(include string, includes []string, exclude []string) func(name string) bool {
+	var selectors []func(name string) bool
+	if strings.TrimSpace(include) != "" {
+		selectors = append(selectors, NewMatcher(include))
+	}
+	for _, pattern := range includes {
+		selectors = append(selectors, NewMatcher(pattern))
+	}
+
+	var rejectors []func(name string) bool
+	for _, pattern := range exclude {
+		rejectors = append(rejectors, NewMatcher(pattern))
+	}
+
+	return func(name string) bool {
+		for _, reject := range rejectors {
+			if reject(name) {
+				return false
+			}
+		}
+		for _, selected := range selectors {
+			if selected(name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ValidateInclude checks the syntax of each gitignore style rule in include. It
+// returns false when include holds no rule that can select a file, i.e. it is
+// empty or every rule is a negation.
+func ValidateInclude(include string) (bool, error) {
+	selects := false
+	for _, line := range strings.Split(include, "\n") {
+		rule := strings.TrimSpace(line)
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(rule, "!")
+		if err := validatePattern(strings.TrimPrefix(rule, "!")); err != nil {
+			return false, fmt.Errorf("invalid include rule %q: %w", rule, err)
+		}
+		if !negated {
+			selects = true
+		}
+	}
+	return selects, nil
+}
+
+// validatePattern checks that each segment of a gitignore style pattern is a
+// valid glob.
+func validatePattern(pattern string) error {
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuleMatches records which of the rules that select files have matched a
+// path, so that rules that never match anything can be reported.
+type RuleMatches struct {
+	rules    []string
+	matchers []func(name string) bool
+	matched  []bool
+}
+
+// NewRuleMatches tracks each rule of include that is not a comment or a
+// negation, and each of the includes patterns.
+func NewRuleMatches(include string, includes []string) *RuleMatches {
+	r := &RuleMatches{}
+	add := func(rule string) {
+		r.rules = append(r.rules, rule)
+		r.matchers = append(r.matchers, NewMatcher(rule))
+		r.matched = append(r.matched, false)
+	}
+
+	for _, line := range strings.Split(include, "\n") {
+		rule := strings.TrimSpace(line)
+		if rule == "" || strings.HasPrefix(rule, "#") || strings.HasPrefix(rule, "!") {
+			continue
+		}
+		add(rule)
+	}
+	for _, pattern := range includes {
+		add(pattern)
+	}
+	return r
+}
+
+// Observe records the rules matching the slash separated path.
+func (r *RuleMatches) Observe(name string) {
+	for i, match := range r.matchers {
+		if !r.matched[i] && match(name) {
+			r.matched[i] = true
+		}
+	}
+}
+
+// Unmatched returns the rules that have not matched any observed path.
+func (r *RuleMatches) Unmatched() []string {
+	var unmatched []string
+	for i, rule := range r.rules {
+		if !r.matched[i] {
+			unmatched = append(unmatched, rule)
+		}
+	}
+	return unmatched
+}
@@ -0,0 +1,173 @@
+package util
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// OpenURL returns the body of url so that it can be streamed, the caller is
+// responsible for closing it.
+func OpenURL(url string) (io.ReadCloser, error) {
+	resp, err := http.Get(validate(url))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to download %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// HashTarGz reads a gzip compressed tarball once, hashing the content of each
+// regular file accepted by match. Nothing is written to disk.
+func HashTarGz(r io.Reader, match func(name string) bool) (Manifest, error) {
+	manifest := Manifest{}
+	err := walkTarGz(r, func(name string, header *tar.Header, content io.Reader) error {
+		if !match(name) {
+			return nil
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, content); err != nil {
+			return err
+		}
+		manifest[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// FilterTarGz reads a gzip compressed tarball once. Each regular file accepted by
+// any of the matches is hashed and copied to the tar writer of every match that
+// accepts it, using header to create its tar header. It returns the manifest of
+// the files accepted by each match.
+func FilterTarGz(r io.Reader, matches []func(name string) bool, writers []*tar.Writer, header func(name string, size int64) *tar.Header) ([]Manifest, error) {
+	manifests := make([]Manifest, len(matches))
+	for i := range manifests {
+		manifests[i] = Manifest{}
+	}
+
+	err := walkTarGz(r, func(name string, h *tar.Header, content io.Reader) error {
+		sum := sha256.New()
+		targets := []io.Writer{sum}
+		var accepted []int
+		for i, match := range matches {
+			if !match(name) {
+				continue
+			}
+			if err := writers[i].WriteHeader(header(name, h.Size)); err != nil {
+				return err
+			}
+			targets = append(targets, writers[i])
+			accepted = append(accepted, i)
+		}
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		if _, err := io.Copy(io.MultiWriter(targets...), content); err != nil {
+			return err
+		}
+		for _, i := range accepted {
+			manifests[i][name] = hex.EncodeToString(sum.Sum(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
+
+// ListTarGz returns the sorted paths of the regular files in a gzip compressed
+// tarball.
+func ListTarGz(r io.Reader) ([]string, error) {
+	var files []string
+	err := walkTarGz(r, func(name string, header *tar.Header, content io.Reader) error {
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadTarGzFile reads a single regular file from a gzip compressed tarball,
+// returning false if the file is not present.
+func ReadTarGzFile(r io.Reader, file string) ([]byte, bool, error) {
+	var content []byte
+	err := walkTarGz(r, func(name string, header *tar.Header, r io.Reader) error {
+		if name != file {
+			return nil
+		}
+		var err error
+		content, err = io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return errStopWalk
+	})
+	if errors.Is(err, errStopWalk) {
+		return content, true, nil
+	}
+	return nil, false, err
+}
+
+// errStopWalk is returned by a walkTarGz callback to stop reading the tarball.
+var errStopWalk = errors.New("stop walk")
+
+// walkTarGz calls fn for each regular file in a gzip compressed tarball with the
+// cleaned, slash separated, path of the file.
+func walkTarGz(r io.Reader, fn func(name string, header *tar.Header, content io.Reader) error) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := cleanArchivePath(header.Name)
+		if err != nil {
+			return err
+		}
+		if err := fn(name, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanArchivePath normalises the name of a tar entry to match the paths listed
+// from an extracted directory, rejecting names that escape the archive.
+func cleanArchivePath(name string) (string, error) {
+	p := strings.TrimPrefix(path.Clean("/"+name), "/")
+	if p == "" || strings.HasPrefix(name, "../") || strings.Contains(name, "/../") {
+		return "", fmt.Errorf("%s: %s", "content filepath is tainted", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,52 @@
+package util_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/garethjevans/monorepository-controller/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashTarGz(t *testing.T) {
+	include := `go.*
+internal/**/*.go
+!**/*_test.go`
+
+	files, err := util.ListFiles("testdata/source")
+	assert.NoError(t, err)
+
+	// archive the files the way the source-controller does, with a leading ./
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join("testdata/source", file))
+		assert.NoError(t, err)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "./" + file,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+		}))
+		_, err = tw.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	manifest, err := util.HashTarGz(&buf, util.NewMatcher(include))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go.mod", "go.sum", "internal/pkg/pkg.go"}, manifest.Files())
+
+	expected, err := util.HashFiles(manifest.Files(), "testdata/source")
+	assert.NoError(t, err)
+
+	actual, err := manifest.Hash1()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
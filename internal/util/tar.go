@@ -50,6 +50,10 @@ func ExtractTarGz(tarGzPath string, dir string) error {
 			if err != nil {
 				return err
 			}
+			// archives are not required to contain an entry for each directory
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
 			outFile, err := os.Create(p)
 			if err != nil {
 				return err
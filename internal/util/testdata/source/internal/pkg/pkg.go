@@ -0,0 +1,5 @@
+package pkg
+
+func Name() string {
+	return "pkg"
+}